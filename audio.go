@@ -0,0 +1,217 @@
+package gcaptcha
+
+import (
+	"math"
+	"time"
+)
+
+/* ================================================================================
+ * 语音验证码
+ * qq group: 582452342
+ * email   : 2091938785@qq.com
+ * author  : 美丽的地球啊 - mliu
+ * ================================================================================ */
+type (
+	Audio struct {
+		option AudioOption
+	}
+
+	AudioOption struct {
+		Bank        ISoundBank    //字符素材库，为空时使用内置数字素材库
+		SampleRate  int           //采样率，默认16000
+		GapMin      time.Duration //字符间随机停顿，默认300ms-700ms
+		GapMax      time.Duration
+		PitchJitter float64 //音调和语速的随机变化比例，默认0.08
+		NoiseLevel  float64 //背景人声和噪声强度，默认0.12，小于0时不加噪声
+		Random      IRandom //随机数源，为空时使用默认源
+	}
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 初始化语音验证码，为视障用户朗读图片验证码的答案
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func NewAudio(option AudioOption) *Audio {
+	audio := &Audio{
+		option: option,
+	}
+
+	if audio.option.Bank == nil {
+		audio.option.Bank = NewDigitSoundBank()
+	}
+
+	if audio.option.SampleRate <= 0 {
+		audio.option.SampleRate = 16000
+	}
+
+	if audio.option.GapMin <= 0 && audio.option.GapMax <= 0 {
+		audio.option.GapMin = 300 * time.Millisecond
+		audio.option.GapMax = 700 * time.Millisecond
+	}
+
+	if audio.option.PitchJitter <= 0 {
+		audio.option.PitchJitter = 0.08
+	}
+
+	if audio.option.NoiseLevel == 0 {
+		audio.option.NoiseLevel = 0.12
+	}
+
+	return audio
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取WAV数据，texts通常为图片的GetText()，按字符依次拼接素材
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *Audio) GetAudio(texts []string) ([]byte, error) {
+	samples, err := s.getSamples(texts)
+	if err != nil {
+		return nil, err
+	}
+
+	return writeWav(samples, s.option.SampleRate)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 拼接字符素材，加入随机停顿、音调语速变化和背景噪声
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *Audio) getSamples(texts []string) ([]float64, error) {
+	random := getRandom(s.option.Random)
+	sampleRate := s.option.SampleRate
+
+	samples := s.getSilence(random)
+	for _, text := range texts {
+		for _, char := range text {
+			sound, err := s.option.Bank.GetSound(string(char), sampleRate)
+			if err != nil {
+				return nil, err
+			}
+
+			jitter := randFloatRange(random, 1-s.option.PitchJitter, 1+s.option.PitchJitter)
+			samples = append(samples, resampleSound(sound, jitter)...)
+			samples = append(samples, s.getSilence(random)...)
+		}
+	}
+
+	if s.option.NoiseLevel > 0 {
+		s.addBabble(samples, random)
+	}
+
+	normalizeSamples(samples)
+
+	return samples, nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 随机长度的停顿
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *Audio) getSilence(random IRandom) []float64 {
+	gap := randFloatRange(random, s.option.GapMin.Seconds(), s.option.GapMax.Seconds())
+
+	return make([]float64, int(gap*float64(s.option.SampleRate)))
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 背景人声：打碎后的素材变调后低音量叠加在任意位置，再加入低通白噪声
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *Audio) addBabble(samples []float64, random IRandom) {
+	level := s.option.NoiseLevel
+	bankTexts := s.option.Bank.GetTexts()
+
+	if len(bankTexts) > 0 {
+		//约每秒3段
+		count := len(samples)*3/s.option.SampleRate + 1
+		for index := 0; index < count; index++ {
+			text := bankTexts[random.Intn(len(bankTexts))]
+			sound, err := s.option.Bank.GetSound(text, s.option.SampleRate)
+			if err != nil || len(sound) == 0 {
+				continue
+			}
+
+			sound = s.getBabbleSound(sound, random)
+			sound = resampleSound(sound, randFloatRange(random, 0.7, 1.3))
+			start := random.Intn(len(samples))
+			volume := level * randFloatRange(random, 0.5, 1)
+
+			for offset, value := range sound {
+				if start+offset >= len(samples) {
+					break
+				}
+				samples[start+offset] += value * volume
+			}
+		}
+	}
+
+	//一阶低通白噪声
+	var previous float64
+	for index := range samples {
+		previous = previous*0.85 + randFloatRange(random, -1, 1)*0.15
+		samples[index] += previous * level
+	}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 将素材切成30-60ms的片段，打乱顺序并逐段倒放，再低通滤波，
+ * 保留人声的音色和节奏，但无法听出原字符，避免与答案混淆
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *Audio) getBabbleSound(sound []float64, random IRandom) []float64 {
+	sampleRate := float64(s.option.SampleRate)
+
+	grains := make([][]float64, 0)
+	for start := 0; start < len(sound); {
+		end := start + int(randFloatRange(random, 0.03, 0.06)*sampleRate)
+		if end > len(sound) {
+			end = len(sound)
+		}
+		grains = append(grains, sound[start:end])
+		start = end
+	}
+
+	for index := len(grains) - 1; index > 0; index-- {
+		swapIndex := random.Intn(index + 1)
+		grains[index], grains[swapIndex] = grains[swapIndex], grains[index]
+	}
+
+	fade := int(0.005 * sampleRate)
+	results := make([]float64, 0, len(sound))
+	for _, grain := range grains {
+		for index := len(grain) - 1; index >= 0; index-- {
+			value := grain[index]
+
+			//片段首尾淡入淡出避免拼接处爆音
+			position := len(grain) - 1 - index
+			if position < fade {
+				value *= float64(position) / float64(fade)
+			} else if index < fade {
+				value *= float64(index) / float64(fade)
+			}
+
+			results = append(results, value)
+		}
+	}
+
+	var previous float64
+	for index, value := range results {
+		previous = previous*0.6 + value*0.4
+		results[index] = previous
+	}
+
+	return results
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 峰值超过0.95时整体缩放
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func normalizeSamples(samples []float64) {
+	var peak float64
+	for _, sample := range samples {
+		peak = math.Max(peak, math.Abs(sample))
+	}
+
+	if peak <= 0.95 {
+		return
+	}
+
+	for index := range samples {
+		samples[index] *= 0.95 / peak
+	}
+}
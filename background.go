@@ -0,0 +1,76 @@
+package gcaptcha
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"path/filepath"
+	"strings"
+)
+
+import (
+	"github.com/sanxia/glib"
+	xdraw "golang.org/x/image/draw"
+)
+
+/* ================================================================================
+ * 背景图片
+ * qq group: 582452342
+ * email   : 2091938785@qq.com
+ * author  : 美丽的地球啊 - mliu
+ * ================================================================================ */
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 读取背景图片，按扩展名解码png或jpg
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func loadBackground(filename string) (image.Image, error) {
+	fileType := "png"
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".jpg", ".jpeg":
+		fileType = "jpg"
+	}
+
+	return glib.GetImageFile(glib.GetAbsolutePath(filename), fileType)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 绘制背景，背景图按比例缩放铺满画布并居中裁剪，filename为空时填充白色
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func drawBackground(dst *image.RGBA, filename string) error {
+	if filename == "" {
+		white := color.RGBA{255, 255, 255, 255}
+		draw.Draw(dst, dst.Bounds(), &image.Uniform{white}, image.ZP, draw.Src)
+		return nil
+	}
+
+	backgroundImage, err := loadBackground(filename)
+	if err != nil {
+		return err
+	}
+
+	xdraw.BiLinear.Scale(dst, dst.Bounds(), backgroundImage, coverRect(backgroundImage.Bounds(), dst.Bounds().Size()), xdraw.Src, nil)
+
+	return nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取src中与size宽高比一致的最大居中区域
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func coverRect(src image.Rectangle, size image.Point) image.Rectangle {
+	if size.X <= 0 || size.Y <= 0 || src.Empty() {
+		return src
+	}
+
+	width, height := src.Dx(), src.Dy()
+	if width*size.Y > height*size.X {
+		width = height * size.X / size.Y
+	} else {
+		height = width * size.Y / size.X
+	}
+
+	x := src.Min.X + (src.Dx()-width)/2
+	y := src.Min.Y + (src.Dy()-height)/2
+
+	return image.Rect(x, y, x+width, y+height)
+}
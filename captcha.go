@@ -0,0 +1,368 @@
+package gcaptcha
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+import (
+	"github.com/sanxia/glib"
+)
+
+/* ================================================================================
+ * 验证码管理
+ * qq group: 582452342
+ * email   : 2091938785@qq.com
+ * author  : 美丽的地球啊 - mliu
+ * ================================================================================ */
+const (
+	answerKindOrdered   = "ordered"   //答案顺序一致
+	answerKindUnordered = "unordered" //答案顺序无关
+	answerKindClick     = "click"     //依次点击区域，答案为 x,y 坐标，容差为像素
+	answerKindSlide     = "slide"     //滑块偏移，答案首项为x，其后可附带 x,y,t 拖动轨迹，容差为像素
+	answerKindAngle     = "angle"     //旋转角度，答案为顺时针角度，容差为度
+)
+
+var (
+	ErrCaptchaStoreEmpty = errors.New("captcha: store is nil")
+	ErrCaptchaIdEmpty    = errors.New("captcha: id is empty")
+	ErrCaptchaIdGenerate = errors.New("captcha: failed to generate id")
+
+	//生成验证码标识，测试时可替换
+	newGuid = glib.Guid
+
+	//容差类答案无法哈希比对，只能保存在服务端
+	toleranceAnswerKinds = map[string]bool{
+		answerKindClick: true,
+		answerKindSlide: true,
+		answerKindAngle: true,
+	}
+)
+
+type (
+	Captcha struct {
+		store  IStore
+		expire time.Duration
+	}
+
+	captchaAnswer struct {
+		Kind         string   `json:"kind"`
+		Texts        []string `json:"texts"`
+		Tolerance    float64  `json:"tolerance,omitempty"`
+		RequireTrack bool     `json:"requireTrack,omitempty"`
+	}
+
+	//答案比对方式，未实现时默认顺序一致
+	answerKinder interface {
+		getAnswerKind() string
+	}
+
+	//容差类答案，比对文本与GetText不同，如点击区域
+	toleranceAnswerer interface {
+		getAnswerTexts() []string
+		getAnswerTolerance() float64
+	}
+
+	//要求附带操作轨迹的答案，如滑块
+	trackAnswerer interface {
+		isTrackRequired() bool
+	}
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 初始化验证码管理
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func NewCaptcha(store IStore, expire time.Duration) *Captcha {
+	return &Captcha{
+		store:  store,
+		expire: expire,
+	}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 生成验证码，返回验证码标识和图片数据
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *Captcha) Generate(img IImage) (string, []byte, error) {
+	if s.store == nil {
+		return "", nil, ErrCaptchaStoreEmpty
+	}
+
+	//随机源不可用时Guid返回空字符串，所有验证码会共用同一个键
+	id := newGuid()
+	if id == "" {
+		return "", nil, ErrCaptchaIdGenerate
+	}
+
+	//文字类图片在GetImage时才生成答案
+	imageData, err := img.GetImage()
+	if err != nil {
+		return "", nil, err
+	}
+
+	answer, err := json.Marshal(newCaptchaAnswer(img))
+	if err != nil {
+		return "", nil, err
+	}
+
+	if err := s.store.Set(id, string(answer), s.expire); err != nil {
+		return "", nil, err
+	}
+
+	if err := s.store.Set(s.imageKey(id), string(imageData), s.expire); err != nil {
+		s.store.Delete(id)
+		return "", nil, err
+	}
+
+	return id, imageData, nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 校验答案，无论成功与否验证码都只能使用一次
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *Captcha) Verify(id string, answers []string) bool {
+	if s.store == nil || id == "" {
+		return false
+	}
+
+	value, err := s.store.Get(id, true)
+	if err != nil {
+		return false
+	}
+
+	s.store.Delete(s.imageKey(id))
+
+	var answer captchaAnswer
+	if err := json.Unmarshal([]byte(value), &answer); err != nil {
+		return false
+	}
+
+	return answer.isMatch(answers)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 作废验证码
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *Captcha) Delete(id string) error {
+	if s.store == nil {
+		return ErrCaptchaStoreEmpty
+	}
+
+	if id == "" {
+		return ErrCaptchaIdEmpty
+	}
+
+	s.store.Delete(s.imageKey(id))
+
+	return s.store.Delete(id)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取验证码图片数据，校验或作废后不可再获取
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *Captcha) GetImage(id string) ([]byte, error) {
+	if s.store == nil {
+		return nil, ErrCaptchaStoreEmpty
+	}
+
+	if id == "" {
+		return nil, ErrCaptchaIdEmpty
+	}
+
+	value, err := s.store.Get(s.imageKey(id), false)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(value), nil
+}
+
+func (s *Captcha) imageKey(id string) string {
+	return id + ":image"
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 根据图片生成答案
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func newCaptchaAnswer(img IImage) *captchaAnswer {
+	answer := &captchaAnswer{
+		Kind:  answerKindOrdered,
+		Texts: img.GetText(),
+	}
+
+	if kinder, ok := img.(answerKinder); ok {
+		answer.Kind = kinder.getAnswerKind()
+	}
+
+	if answerer, ok := img.(toleranceAnswerer); ok {
+		answer.Texts = answerer.getAnswerTexts()
+		answer.Tolerance = answerer.getAnswerTolerance()
+	}
+
+	if answerer, ok := img.(trackAnswerer); ok {
+		answer.RequireTrack = answerer.isTrackRequired()
+	}
+
+	return answer
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 比对答案，忽略首尾空白和大小写
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *captchaAnswer) isMatch(answers []string) bool {
+	switch s.Kind {
+	case answerKindClick:
+		return s.isClickMatch(answers)
+	case answerKindSlide:
+		return s.isSlideMatch(answers)
+	case answerKindAngle:
+		return s.isAngleMatch(answers)
+	}
+
+	if len(s.Texts) == 0 || len(s.Texts) != len(answers) {
+		return false
+	}
+
+	expects := normalizeAnswers(s.Texts)
+	actuals := normalizeAnswers(answers)
+
+	if s.Kind == answerKindUnordered {
+		sort.Strings(expects)
+		sort.Strings(actuals)
+	}
+
+	for index, expect := range expects {
+		if expect != actuals[index] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func normalizeAnswers(answers []string) []string {
+	results := make([]string, 0, len(answers))
+	for _, answer := range answers {
+		results = append(results, strings.ToLower(strings.TrimSpace(answer)))
+	}
+
+	return results
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 比对点击坐标，Texts为依次点击的区域 minX,minY,maxX,maxY，
+ * 每个点击点需按顺序落在对应区域向外扩展Tolerance像素的范围内
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *captchaAnswer) isClickMatch(answers []string) bool {
+	points, err := parseAnswerNumbers(answers)
+	if err != nil || len(s.Texts) == 0 || len(points) != len(s.Texts)*2 {
+		return false
+	}
+
+	for index, text := range s.Texts {
+		rect, err := parseAnswerNumbers([]string{text})
+		if err != nil || len(rect) != 4 {
+			return false
+		}
+
+		x, y := points[index*2], points[index*2+1]
+		if x < rect[0]-s.Tolerance || x > rect[2]+s.Tolerance ||
+			y < rect[1]-s.Tolerance || y > rect[3]+s.Tolerance {
+			return false
+		}
+	}
+
+	return true
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 比对滑块偏移，Texts为唯一的期望x，附带轨迹时轨迹需通过IsHumanTrack，
+ * RequireTrack时必须附带轨迹
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *captchaAnswer) isSlideMatch(answers []string) bool {
+	if len(s.Texts) != 1 || len(answers) == 0 {
+		return false
+	}
+
+	expects, err := parseAnswerNumbers(s.Texts)
+	if err != nil || len(expects) != 1 {
+		return false
+	}
+
+	numbers, err := parseAnswerNumbers(answers[:1])
+	if err != nil || len(numbers) != 1 {
+		return false
+	}
+
+	if math.Abs(numbers[0]-expects[0]) > s.Tolerance {
+		return false
+	}
+
+	if len(answers) == 1 {
+		return !s.RequireTrack
+	}
+
+	trackNumbers, err := parseAnswerNumbers(answers[1:])
+	if err != nil || len(trackNumbers)%3 != 0 {
+		return false
+	}
+
+	track := make([]SlidePoint, 0, len(trackNumbers)/3)
+	for index := 0; index < len(trackNumbers); index += 3 {
+		track = append(track, SlidePoint{
+			X: trackNumbers[index],
+			Y: trackNumbers[index+1],
+			T: int64(trackNumbers[index+2]),
+		})
+	}
+
+	return IsHumanTrack(track, numbers[0], s.Tolerance)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 比对旋转角度，按圆周计算差值，如 358 与 2 相差4度
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *captchaAnswer) isAngleMatch(answers []string) bool {
+	expects, err := parseAnswerNumbers(s.Texts)
+	if err != nil || len(expects) != 1 {
+		return false
+	}
+
+	numbers, err := parseAnswerNumbers(answers)
+	if err != nil || len(numbers) != 1 {
+		return false
+	}
+
+	difference := math.Mod(math.Abs(numbers[0]-expects[0]), 360)
+	difference = math.Min(difference, 360-difference)
+
+	return difference <= s.Tolerance
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 解析数值答案，每个答案可以是单个数值或逗号分隔的多个数值，
+ * 如 ["12,30", "40,52"] 与 ["12", "30", "40", "52"] 等价
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func parseAnswerNumbers(answers []string) ([]float64, error) {
+	numbers := make([]float64, 0)
+	for _, answer := range answers {
+		for _, text := range strings.Split(answer, ",") {
+			number, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+			if err != nil {
+				return nil, err
+			}
+
+			if math.IsNaN(number) || math.IsInf(number, 0) {
+				return nil, errors.New("captcha: invalid number")
+			}
+
+			numbers = append(numbers, number)
+		}
+	}
+
+	return numbers, nil
+}
@@ -0,0 +1,127 @@
+package gcaptcha
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 滑块答案：首项为x，其后为轨迹点 x,y,t
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func slideAnswers(x float64, track []SlidePoint) []string {
+	answers := []string{fmt.Sprint(x)}
+	for _, point := range track {
+		answers = append(answers, fmt.Sprintf("%v,%v,%d", point.X, point.Y, point.T))
+	}
+
+	return answers
+}
+
+func TestCaptchaAnswerIsMatch(t *testing.T) {
+	ordered := &captchaAnswer{Kind: answerKindOrdered, Texts: []string{"A", "b", "C"}}
+	unordered := &captchaAnswer{Kind: answerKindUnordered, Texts: []string{"1", "4", "7"}}
+	click := &captchaAnswer{Kind: answerKindClick, Texts: []string{"10,10,30,30", "50,10,70,30"}, Tolerance: 2}
+	slide := &captchaAnswer{Kind: answerKindSlide, Texts: []string{"120"}, Tolerance: 4}
+	slideTrack := &captchaAnswer{Kind: answerKindSlide, Texts: []string{"120"}, Tolerance: 4, RequireTrack: true}
+	angle := &captchaAnswer{Kind: answerKindAngle, Texts: []string{"358"}, Tolerance: 5}
+
+	tests := []struct {
+		name    string
+		answer  *captchaAnswer
+		answers []string
+		want    bool
+	}{
+		{"ordered exact", ordered, []string{"A", "b", "C"}, true},
+		{"ordered case and space", ordered, []string{" a", "B ", "c"}, true},
+		{"ordered wrong order", ordered, []string{"b", "A", "C"}, false},
+		{"ordered missing", ordered, []string{"A", "b"}, false},
+		{"ordered extra", ordered, []string{"A", "b", "C", "D"}, false},
+		{"ordered empty", ordered, nil, false},
+		{"ordered no texts", &captchaAnswer{Kind: answerKindOrdered}, nil, false},
+
+		{"unordered any order", unordered, []string{"7", "1", "4"}, true},
+		{"unordered wrong", unordered, []string{"7", "1", "5"}, false},
+		{"unordered duplicate", unordered, []string{"1", "1", "4"}, false},
+
+		{"click inside", click, []string{"20,20", "60,20"}, true},
+		{"click split numbers", click, []string{"20", "20", "60", "20"}, true},
+		{"click within tolerance", click, []string{"8,32", "72,8"}, true},
+		{"click outside tolerance", click, []string{"7,20", "60,20"}, false},
+		{"click wrong order", click, []string{"60,20", "20,20"}, false},
+		{"click missing point", click, []string{"20,20"}, false},
+		{"click not a number", click, []string{"a,20", "60,20"}, false},
+
+		{"slide exact", slide, []string{"120"}, true},
+		{"slide within tolerance", slide, []string{"116.5"}, true},
+		{"slide outside tolerance", slide, []string{"125"}, false},
+		{"slide human track", slide, slideAnswers(121, humanTrack(121)), true},
+		{"slide track ends elsewhere", slide, slideAnswers(121, humanTrack(60)), false},
+		{"slide robot track", slide, slideAnswers(120, []SlidePoint{{0, 0, 0}, {120, 0, 10}}), false},
+		{"slide broken track", slide, []string{"120", "1,2"}, false},
+		{"slide empty", slide, nil, false},
+		{"slide require track without track", slideTrack, []string{"120"}, false},
+		{"slide require track with track", slideTrack, slideAnswers(120, humanTrack(120)), true},
+
+		{"angle exact", angle, []string{"358"}, true},
+		{"angle across zero", angle, []string{"2"}, true},
+		{"angle full turn", angle, []string{"-2"}, true},
+		{"angle outside tolerance", angle, []string{"10"}, false},
+		{"angle two numbers", angle, []string{"358", "1"}, false},
+		{"angle not a number", angle, []string{"NaN"}, false},
+	}
+
+	for _, test := range tests {
+		if got := test.answer.isMatch(test.answers); got != test.want {
+			t.Errorf("%s: isMatch(%q) = %v, want %v", test.name, test.answers, got, test.want)
+		}
+	}
+}
+
+func TestCaptchaGenerateErrors(t *testing.T) {
+	img := newTestTextImage([]string{"a", "b"}, 2)
+
+	if _, _, err := NewCaptcha(nil, time.Minute).Generate(img); err != ErrCaptchaStoreEmpty {
+		t.Errorf("nil store: err = %v, want ErrCaptchaStoreEmpty", err)
+	}
+
+	store := NewMemoryStore(time.Minute)
+	defer store.Close()
+
+	guid := newGuid
+	newGuid = func() string { return "" }
+	defer func() { newGuid = guid }()
+
+	if _, _, err := NewCaptcha(store, time.Minute).Generate(img); err != ErrCaptchaIdGenerate {
+		t.Errorf("empty guid: err = %v, want ErrCaptchaIdGenerate", err)
+	}
+}
+
+func TestCaptchaVerifyOnce(t *testing.T) {
+	store := NewMemoryStore(time.Minute)
+	defer store.Close()
+
+	captcha := NewCaptcha(store, time.Minute)
+
+	img := newTestTextImage([]string{"a", "b", "c", "d"}, 3)
+	id, _, err := captcha.Generate(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := captcha.GetImage(id); err != nil {
+		t.Fatalf("GetImage: %v", err)
+	}
+
+	if !captcha.Verify(id, img.GetText()) {
+		t.Fatal("Verify rejected the correct answer")
+	}
+
+	if captcha.Verify(id, img.GetText()) {
+		t.Fatal("Verify accepted a used id")
+	}
+
+	if _, err := captcha.GetImage(id); err == nil {
+		t.Fatal("GetImage returned the image of a used id")
+	}
+}
@@ -0,0 +1,450 @@
+package gcaptcha
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strings"
+)
+
+import (
+	"github.com/golang/freetype"
+)
+
+/* ================================================================================
+ * 依次点击文字图片
+ * qq group: 582452342
+ * email   : 2091938785@qq.com
+ * author  : 美丽的地球啊 - mliu
+ * ================================================================================ */
+const (
+	clickPlaceRetryCount = 100
+)
+
+type (
+	clickImage struct {
+		title       string
+		texts       []string //外部数据源，每项为一个字符
+		option      ImageOption
+		clickOption ClickOption
+		colors      []*image.Uniform
+		targets     []string          //按点击顺序的目标文字
+		decoys      []string          //干扰文字
+		rects       []image.Rectangle //目标文字在画布中的区域，与targets顺序一致
+		isGenerated bool
+		generateErr error
+	}
+
+	ClickOption struct {
+		Width      int     //画布宽度，默认300
+		Height     int     //画布高度（不含标题），默认160
+		Count      int     //需要点击的文字数，默认4
+		DecoyCount int     //不需要点击的干扰文字数，默认0
+		Rotation   float64 //最大旋转角度（度），0为不旋转，建议40
+		Tolerance  float64 //点击区域向外扩展的容差（像素），默认4
+	}
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 初始化依次点击文字图，如 请依次点击 '春' '眠' '不' '觉'，
+ * 中文需要通过FontPath或Fonts指定中文字体
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func NewClickImage(title string, texts []string, option ClickOption) *clickImage {
+	clickImage := &clickImage{
+		title: title,
+		texts: texts,
+		option: ImageOption{
+			FontSize: 24,
+		},
+		clickOption: option,
+	}
+
+	if clickImage.clickOption.Width <= 0 {
+		clickImage.clickOption.Width = 300
+	}
+
+	if clickImage.clickOption.Height <= 0 {
+		clickImage.clickOption.Height = 160
+	}
+
+	if clickImage.clickOption.Count <= 0 {
+		clickImage.clickOption.Count = 4
+	}
+
+	if clickImage.clickOption.Rotation < 0 {
+		clickImage.clickOption.Rotation = 0
+	}
+
+	if clickImage.clickOption.Tolerance <= 0 {
+		clickImage.clickOption.Tolerance = 4
+	}
+
+	clickImage.colors = make([]*image.Uniform, 0)
+	clickImage.colors = append(clickImage.colors, &image.Uniform{color.RGBA{230, 60, 50, 255}})
+	clickImage.colors = append(clickImage.colors, &image.Uniform{color.RGBA{250, 200, 40, 255}})
+	clickImage.colors = append(clickImage.colors, &image.Uniform{color.RGBA{60, 200, 90, 255}})
+	clickImage.colors = append(clickImage.colors, &image.Uniform{color.RGBA{60, 150, 240, 255}})
+
+	return clickImage
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取标题，包含按顺序需要点击的文字
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *clickImage) GetTitle() string {
+	if err := s.generate(); err != nil {
+		return s.title
+	}
+
+	quotes := make([]string, 0, len(s.targets))
+	for _, target := range s.targets {
+		quotes = append(quotes, "'"+target+"'")
+	}
+
+	return strings.TrimSpace(s.title + " " + strings.Join(quotes, " "))
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取按点击顺序的目标文字
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *clickImage) GetText() []string {
+	if err := s.generate(); err != nil {
+		return nil
+	}
+
+	return s.targets
+}
+
+func (s *clickImage) SetOption(option ImageOption) {
+	s.option = option
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取目标文字在画布中的区域，与GetText顺序一致，GetImage后有效
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *clickImage) GetRects() []image.Rectangle {
+	return s.rects
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 校验按顺序点击的画布坐标
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *clickImage) VerifyClicks(points []image.Point) bool {
+	answers := make([]string, 0, len(points))
+	for _, point := range points {
+		answers = append(answers, fmt.Sprintf("%d,%d", point.X, point.Y))
+	}
+
+	return newCaptchaAnswer(s).isMatch(answers)
+}
+
+func (s *clickImage) getAnswerKind() string {
+	return answerKindClick
+}
+
+func (s *clickImage) getAnswerTexts() []string {
+	texts := make([]string, 0, len(s.rects))
+	for _, rect := range s.rects {
+		texts = append(texts, fmt.Sprintf("%d,%d,%d,%d", rect.Min.X, rect.Min.Y, rect.Max.X, rect.Max.Y))
+	}
+
+	return texts
+}
+
+func (s *clickImage) getAnswerTolerance() float64 {
+	return s.clickOption.Tolerance
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 随机选择目标文字和干扰文字，首次获取标题、文字或图片时执行
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *clickImage) generate() error {
+	if s.isGenerated {
+		return s.generateErr
+	}
+	s.isGenerated = true
+
+	count := s.clickOption.Count + s.clickOption.DecoyCount
+	if len(s.texts) < count {
+		s.generateErr = fmt.Errorf("click image: need %d texts, got %d", count, len(s.texts))
+		return s.generateErr
+	}
+
+	indexs := make([]int, len(s.texts))
+	for index := range indexs {
+		indexs[index] = index
+	}
+
+	//部分洗牌取前count个
+	random := s.answerRandom()
+	for index := 0; index < count; index++ {
+		swapIndex := randIntRange(random, index, len(indexs))
+		indexs[index], indexs[swapIndex] = indexs[swapIndex], indexs[index]
+	}
+
+	for index := 0; index < count; index++ {
+		if index < s.clickOption.Count {
+			s.targets = append(s.targets, s.texts[indexs[index]])
+		} else {
+			s.decoys = append(s.decoys, s.texts[indexs[index]])
+		}
+	}
+
+	return nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取图片数据，按Output选择的格式编码
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *clickImage) GetImage() ([]byte, error) {
+	img, err := s.GetRawImage()
+	if err != nil {
+		return nil, err
+	}
+
+	return encodeImage(img, s.option.Output)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取未编码的图片
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *clickImage) GetRawImage() (image.Image, error) {
+	if err := s.generate(); err != nil {
+		return nil, err
+	}
+
+	headerHeight := s.option.HeaderHeight
+	width := s.clickOption.Width
+	height := s.clickOption.Height + headerHeight
+
+	graphics := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	//标题
+	if headerHeight > 0 {
+		draw.Draw(graphics, image.Rect(0, 0, width, headerHeight), image.White, image.ZP, draw.Src)
+		if err := s.drawTitle(graphics); err != nil {
+			return nil, err
+		}
+	}
+
+	//背景图
+	area := image.Rect(0, headerHeight, width, height)
+	if err := drawBackground(graphics.SubImage(area).(*image.RGBA), s.option.Backgroud); err != nil {
+		return nil, err
+	}
+
+	//文字，目标与干扰文字混合后放置
+	rects, err := s.drawTexts(graphics, area.Inset(s.option.Padding))
+	if err != nil {
+		return nil, err
+	}
+	s.rects = rects
+
+	//噪声
+	drawNoises(graphics, s.option.Noises, nil, s.random())
+
+	//滤镜会使文字偏离记录的区域，需配合容差使用较小的强度
+	graphics = applyFilters(graphics, s.option.Filters, s.random())
+
+	return graphics, nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 绘制标题
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *clickImage) drawTitle(graphics *image.RGBA) error {
+	font, err := GetFont(s.option.FontPath)
+	if err != nil {
+		return err
+	}
+
+	title := s.GetTitle()
+	if err := checkFontGlyphs(font, title); err != nil {
+		return err
+	}
+
+	fontSize := float64(s.option.HeaderHeight) * 0.6
+
+	ctx := freetype.NewContext()
+	ctx.SetDPI(72)
+	ctx.SetFontSize(fontSize)
+	ctx.SetFont(font)
+	ctx.SetClip(image.Rect(0, 0, graphics.Bounds().Dx(), s.option.HeaderHeight))
+	ctx.SetDst(graphics)
+	ctx.SetSrc(image.Black)
+
+	pt := freetype.Pt(s.option.Padding+2, int(float64(s.option.HeaderHeight)*0.5+fontSize*0.35))
+	if _, err := ctx.DrawString(title, pt); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 在area内随机不重叠地放置并旋转绘制文字，返回目标文字的区域，
+ * 文字区域即答案，顺序、大小、角度和位置都使用答案随机数源
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *clickImage) drawTexts(graphics *image.RGBA, area image.Rectangle) ([]image.Rectangle, error) {
+	fonts, err := loadFonts(s.option.Fonts, s.option.FontPath)
+	if err != nil {
+		return nil, err
+	}
+
+	random := s.answerRandom()
+
+	//绘制顺序随机，避免目标文字总是先放置
+	texts := append(append([]string{}, s.targets...), s.decoys...)
+	orders := make([]int, len(texts))
+	for index := range orders {
+		orders[index] = index
+	}
+	for index := len(orders) - 1; index > 0; index-- {
+		swapIndex := random.Intn(index + 1)
+		orders[index], orders[swapIndex] = orders[swapIndex], orders[index]
+	}
+
+	rects := make([]image.Rectangle, len(texts))
+	placedRects := make([]image.Rectangle, 0, len(texts))
+
+	for _, order := range orders {
+		text := texts[order]
+		fontSize := s.option.FontSize * randFloatRange(random, 1, 1.3)
+
+		layer, inkRect, err := s.getTextLayer(text, fontSize, fonts)
+		if err != nil {
+			return nil, err
+		}
+
+		//旋转后的外接矩形尺寸
+		angle := randFloatRange(random, -s.clickOption.Rotation, s.clickOption.Rotation) * math.Pi / 180
+		sin, cos := math.Sincos(angle)
+		inkWidth, inkHeight := float64(inkRect.Dx()), float64(inkRect.Dy())
+		rotatedWidth := inkWidth*math.Abs(cos) + inkHeight*math.Abs(sin)
+		rotatedHeight := inkWidth*math.Abs(sin) + inkHeight*math.Abs(cos)
+
+		rect, ok := s.placeRect(random, area, rotatedWidth, rotatedHeight, placedRects)
+		if !ok {
+			return nil, fmt.Errorf("click image: no room for %d texts in %dx%d", len(texts), area.Dx(), area.Dy())
+		}
+		placedRects = append(placedRects, rect)
+		rects[order] = rect
+
+		center := [2]float64{
+			float64(inkRect.Min.X+inkRect.Max.X) / 2,
+			float64(inkRect.Min.Y+inkRect.Max.Y) / 2,
+		}
+		target := [2]float64{
+			float64(rect.Min.X+rect.Max.X) / 2,
+			float64(rect.Min.Y+rect.Max.Y) / 2,
+		}
+
+		drawTransformed(graphics, layer, center, target, [4]float64{cos, -sin, sin, cos})
+	}
+
+	return rects[:len(s.targets)], nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 将文字绘制到独立图层，带深色阴影便于在照片背景上辨认，同时返回图层中的笔画区域
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *clickImage) getTextLayer(text string, fontSize float64, fonts []*weightedFont) (*image.RGBA, image.Rectangle, error) {
+	layerSize := int(fontSize*2) + 8
+	layer := image.NewRGBA(image.Rect(0, 0, layerSize, layerSize))
+	layerPoint := freetype.Pt(layerSize/4, layerSize*3/4)
+
+	ctx := freetype.NewContext()
+	ctx.SetDPI(72)
+	ctx.SetFontSize(fontSize)
+	ctx.SetClip(layer.Bounds())
+	ctx.SetDst(layer)
+
+	for _, char := range text {
+		font, err := pickFont(s.random(), fonts, char)
+		if err != nil {
+			return nil, image.ZR, err
+		}
+		ctx.SetFont(font)
+		break
+	}
+
+	shadowPoint := layerPoint.Add(freetype.Pt(1, 1))
+	ctx.SetSrc(image.NewUniform(color.RGBA{0, 0, 0, 160}))
+	if _, err := ctx.DrawString(text, shadowPoint); err != nil {
+		return nil, image.ZR, err
+	}
+
+	ctx.SetSrc(s.colors[s.random().Intn(len(s.colors))])
+	if _, err := ctx.DrawString(text, layerPoint); err != nil {
+		return nil, image.ZR, err
+	}
+
+	return layer, alphaBounds(layer), nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 在area内随机选择与已放置区域不重叠的位置
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *clickImage) placeRect(random IRandom, area image.Rectangle, width, height float64, placedRects []image.Rectangle) (image.Rectangle, bool) {
+	rectWidth, rectHeight := int(math.Ceil(width)), int(math.Ceil(height))
+	if rectWidth > area.Dx() || rectHeight > area.Dy() {
+		return image.ZR, false
+	}
+
+	for i := 0; i < clickPlaceRetryCount; i++ {
+		x := randIntRange(random, area.Min.X, area.Max.X-rectWidth+1)
+		y := randIntRange(random, area.Min.Y, area.Max.Y-rectHeight+1)
+		rect := image.Rect(x, y, x+rectWidth, y+rectHeight)
+
+		isOverlap := false
+		for _, placedRect := range placedRects {
+			//保留间距，容差范围不会覆盖相邻文字
+			if rect.Inset(-int(s.clickOption.Tolerance)).Overlaps(placedRect) {
+				isOverlap = true
+				break
+			}
+		}
+
+		if !isOverlap {
+			return rect, true
+		}
+	}
+
+	return image.ZR, false
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取随机数源
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *clickImage) random() IRandom {
+	return getRandom(s.option.Random)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取答案选择随机数源
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *clickImage) answerRandom() IRandom {
+	return getAnswerRandom(s.option.Random, s.option.IsSecure)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取图片中非透明像素的外接矩形
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func alphaBounds(img *image.RGBA) image.Rectangle {
+	bounds := img.Bounds()
+	rect := image.ZR
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if img.Pix[img.PixOffset(x, y)+3] == 0 {
+				continue
+			}
+
+			rect = rect.Union(image.Rect(x, y, x+1, y+1))
+		}
+	}
+
+	return rect
+}
@@ -0,0 +1,162 @@
+package gcaptcha
+
+import (
+	"image"
+	"reflect"
+	"testing"
+)
+
+func newTestClickImage(seed int64, isSecure bool) *clickImage {
+	img := NewClickImage("请依次点击", []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"}, ClickOption{
+		Count:      3,
+		DecoyCount: 3,
+		Rotation:   40,
+	})
+	img.SetOption(ImageOption{
+		FontSize: 24,
+		Padding:  4,
+		Random:   NewRandom(seed),
+		IsSecure: isSecure,
+	})
+
+	return img
+}
+
+func TestClickImageTargetsAndDecoys(t *testing.T) {
+	img := newTestClickImage(1, false)
+	if _, err := img.GetImage(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(img.targets) != 3 || len(img.decoys) != 3 {
+		t.Fatalf("got %d targets and %d decoys, want 3 and 3", len(img.targets), len(img.decoys))
+	}
+
+	seen := make(map[string]bool)
+	for _, text := range append(append([]string{}, img.targets...), img.decoys...) {
+		if seen[text] {
+			t.Errorf("text %q used twice", text)
+		}
+		seen[text] = true
+	}
+
+	if !reflect.DeepEqual(img.GetText(), img.targets) || len(img.GetRects()) != 3 {
+		t.Errorf("GetText %v, %d rects, want targets %v with 3 rects", img.GetText(), len(img.GetRects()), img.targets)
+	}
+
+	if title := img.GetTitle(); title != "请依次点击 '"+img.targets[0]+"' '"+img.targets[1]+"' '"+img.targets[2]+"'" {
+		t.Errorf("title %q", title)
+	}
+
+	tooMany := NewClickImage("", []string{"a", "b"}, ClickOption{Count: 2, DecoyCount: 1})
+	if _, err := tooMany.GetImage(); err == nil {
+		t.Error("GetImage succeeded with fewer texts than Count+DecoyCount")
+	}
+}
+
+func TestClickImagePlacement(t *testing.T) {
+	for seed := int64(1); seed <= 20; seed++ {
+		img := newTestClickImage(seed, false)
+		if _, err := img.GetImage(); err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+
+		area := image.Rect(0, 0, img.clickOption.Width, img.clickOption.Height).Inset(img.option.Padding)
+		tolerance := int(img.clickOption.Tolerance)
+
+		rects := img.GetRects()
+		for index, rect := range rects {
+			if rect.Empty() || !rect.In(area) {
+				t.Errorf("seed %d: rect %v not inside %v", seed, rect, area)
+			}
+
+			for otherIndex, other := range rects {
+				if otherIndex != index && rect.Inset(-tolerance).Overlaps(other) {
+					t.Errorf("seed %d: rect %v within tolerance of %v", seed, rect, other)
+				}
+			}
+		}
+	}
+
+	//已放置区域覆盖整个画布时无处放置
+	img := newTestClickImage(1, false)
+	area := image.Rect(0, 0, 100, 40)
+	if _, ok := img.placeRect(NewRandom(1), area, 20, 20, []image.Rectangle{area}); ok {
+		t.Error("placeRect placed a rect over an existing one")
+	}
+
+	if _, ok := img.placeRect(NewRandom(1), area, 120, 20, nil); ok {
+		t.Error("placeRect placed a rect larger than the area")
+	}
+}
+
+func TestClickImageVerifyClicks(t *testing.T) {
+	img := newTestClickImage(2, false)
+	if _, err := img.GetImage(); err != nil {
+		t.Fatal(err)
+	}
+
+	rects := img.GetRects()
+	centers := make([]image.Point, 0, len(rects))
+	for _, rect := range rects {
+		centers = append(centers, image.Pt((rect.Min.X+rect.Max.X)/2, (rect.Min.Y+rect.Max.Y)/2))
+	}
+
+	tolerance := int(img.clickOption.Tolerance)
+	edge := image.Pt(rects[0].Min.X-tolerance, rects[0].Min.Y)
+	outside := image.Pt(rects[0].Min.X-tolerance-1, rects[0].Min.Y)
+
+	tests := []struct {
+		name   string
+		points []image.Point
+		want   bool
+	}{
+		{"in order", centers, true},
+		{"within tolerance", []image.Point{edge, centers[1], centers[2]}, true},
+		{"wrong order", []image.Point{centers[1], centers[0], centers[2]}, false},
+		{"outside tolerance", []image.Point{outside, centers[1], centers[2]}, false},
+		{"missing click", centers[:2], false},
+		{"no clicks", nil, false},
+	}
+
+	for _, test := range tests {
+		if got := img.VerifyClicks(test.points); got != test.want {
+			t.Errorf("%s: VerifyClicks(%v) = %v, want %v", test.name, test.points, got, test.want)
+		}
+	}
+}
+
+func TestClickImageSecurePlacement(t *testing.T) {
+	getRects := func(isSecure bool) []image.Rectangle {
+		img := newTestClickImage(3, isSecure)
+		if _, err := img.GetImage(); err != nil {
+			t.Fatal(err)
+		}
+
+		return img.GetRects()
+	}
+
+	if !reflect.DeepEqual(getRects(false), getRects(false)) {
+		t.Error("same seed placed texts differently")
+	}
+
+	//安全模式下位置不由固定种子决定
+	if reflect.DeepEqual(getRects(true), getRects(true)) {
+		t.Error("secure mode placed texts from the seeded random source")
+	}
+}
+
+func TestClickImageOption(t *testing.T) {
+	img := NewClickImage("", nil, ClickOption{})
+	if img.clickOption.Rotation != 0 {
+		t.Errorf("rotation %v, want 0 to stay 0", img.clickOption.Rotation)
+	}
+
+	//白色背景上需要可见
+	for _, uniform := range img.colors {
+		r, g, b, _ := uniform.C.RGBA()
+		if r>>8 > 240 && g>>8 > 240 && b>>8 > 240 {
+			t.Errorf("color %v is invisible on white", uniform.C)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package gcaptcha
+
+import (
+	"image"
+	"math"
+)
+
+/* ================================================================================
+ * 图片扭曲滤镜
+ * qq group: 582452342
+ * email   : 2091938785@qq.com
+ * author  : 美丽的地球啊 - mliu
+ * ================================================================================ */
+type (
+	//滤镜作用于合成后的画布，在编码前按顺序执行
+	IFilter interface {
+		Filter(img *image.RGBA, random IRandom) *image.RGBA
+	}
+
+	//正弦波扭曲
+	WaveFilter struct {
+		Strength float64 //振幅（像素）
+		Period   float64 //周期（像素），默认32
+	}
+
+	//弹性扭曲
+	ElasticFilter struct {
+		Strength float64 //最大位移（像素）
+		Cell     float64 //随机位移网格大小（像素），越大越平滑，默认16
+	}
+
+	//漩涡扭曲
+	SwirlFilter struct {
+		Strength float64 //中心处旋转角度（弧度）
+		Radius   float64 //作用半径（像素），默认为短边的一半
+	}
+
+	//水波纹扭曲
+	RippleFilter struct {
+		Strength   float64 //振幅（像素）
+		Wavelength float64 //波长（像素），默认16
+	}
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 依次执行滤镜
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func applyFilters(img *image.RGBA, filters []IFilter, random IRandom) *image.RGBA {
+	for _, filter := range filters {
+		if filter != nil {
+			img = filter.Filter(img, random)
+		}
+	}
+
+	return img
+}
+
+func (s WaveFilter) Filter(img *image.RGBA, random IRandom) *image.RGBA {
+	if s.Strength == 0 {
+		return img
+	}
+
+	period := s.Period
+	if period <= 0 {
+		period = 32
+	}
+
+	phaseX := random.Float64() * 2 * math.Pi
+	phaseY := random.Float64() * 2 * math.Pi
+
+	return remapImage(img, func(x, y float64) (float64, float64) {
+		return x + s.Strength*math.Sin(2*math.Pi*y/period+phaseX),
+			y + s.Strength*math.Sin(2*math.Pi*x/period+phaseY)
+	})
+}
+
+func (s ElasticFilter) Filter(img *image.RGBA, random IRandom) *image.RGBA {
+	if s.Strength == 0 {
+		return img
+	}
+
+	cell := s.Cell
+	if cell <= 0 {
+		cell = 16
+	}
+
+	//粗网格随机位移，像素位移由双线性插值得到
+	bounds := img.Bounds()
+	columns := int(math.Ceil(float64(bounds.Dx())/cell)) + 2
+	rows := int(math.Ceil(float64(bounds.Dy())/cell)) + 2
+
+	offsets := make([][2]float64, rows*columns)
+	for index := range offsets {
+		offsets[index] = [2]float64{
+			randFloatRange(random, -s.Strength, s.Strength),
+			randFloatRange(random, -s.Strength, s.Strength),
+		}
+	}
+
+	return remapImage(img, func(x, y float64) (float64, float64) {
+		gx := (x - float64(bounds.Min.X)) / cell
+		gy := (y - float64(bounds.Min.Y)) / cell
+		x0, y0 := int(gx), int(gy)
+		fx, fy := gx-float64(x0), gy-float64(y0)
+
+		offset := func(column, row, axis int) float64 {
+			return offsets[row*columns+column][axis]
+		}
+
+		var delta [2]float64
+		for axis := 0; axis < 2; axis++ {
+			top := offset(x0, y0, axis)*(1-fx) + offset(x0+1, y0, axis)*fx
+			bottom := offset(x0, y0+1, axis)*(1-fx) + offset(x0+1, y0+1, axis)*fx
+			delta[axis] = top*(1-fy) + bottom*fy
+		}
+
+		return x + delta[0], y + delta[1]
+	})
+}
+
+func (s SwirlFilter) Filter(img *image.RGBA, random IRandom) *image.RGBA {
+	if s.Strength == 0 {
+		return img
+	}
+
+	bounds := img.Bounds()
+	radius := s.Radius
+	if radius <= 0 {
+		radius = math.Min(float64(bounds.Dx()), float64(bounds.Dy())) / 2
+	}
+
+	//中心在画布中部附近随机
+	centerX := float64(bounds.Min.X) + float64(bounds.Dx())*randFloatRange(random, 0.35, 0.65)
+	centerY := float64(bounds.Min.Y) + float64(bounds.Dy())*randFloatRange(random, 0.35, 0.65)
+
+	return remapImage(img, func(x, y float64) (float64, float64) {
+		dx, dy := x-centerX, y-centerY
+		distance := math.Hypot(dx, dy)
+		if distance >= radius {
+			return x, y
+		}
+
+		ratio := 1 - distance/radius
+		sin, cos := math.Sincos(s.Strength * ratio * ratio)
+
+		return centerX + dx*cos - dy*sin, centerY + dx*sin + dy*cos
+	})
+}
+
+func (s RippleFilter) Filter(img *image.RGBA, random IRandom) *image.RGBA {
+	if s.Strength == 0 {
+		return img
+	}
+
+	wavelength := s.Wavelength
+	if wavelength <= 0 {
+		wavelength = 16
+	}
+
+	bounds := img.Bounds()
+	centerX := float64(bounds.Min.X) + float64(bounds.Dx())*random.Float64()
+	centerY := float64(bounds.Min.Y) + float64(bounds.Dy())*random.Float64()
+	phase := random.Float64() * 2 * math.Pi
+
+	return remapImage(img, func(x, y float64) (float64, float64) {
+		dx, dy := x-centerX, y-centerY
+		distance := math.Hypot(dx, dy)
+		if distance == 0 {
+			return x, y
+		}
+
+		offset := s.Strength * math.Sin(2*math.Pi*distance/wavelength+phase)
+
+		return x + dx/distance*offset, y + dy/distance*offset
+	})
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 逆向映射重采样，mapping返回目标像素中心对应的源坐标，超出边界取边缘像素
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func remapImage(src *image.RGBA, mapping func(x, y float64) (float64, float64)) *image.RGBA {
+	bounds := src.Bounds()
+	dst := image.NewRGBA(bounds)
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			sx, sy := mapping(float64(x)+0.5, float64(y)+0.5)
+			r, g, b, a := sampleBilinear(src, sx-0.5, sy-0.5)
+
+			offset := dst.PixOffset(x, y)
+			dst.Pix[offset+0] = r
+			dst.Pix[offset+1] = g
+			dst.Pix[offset+2] = b
+			dst.Pix[offset+3] = a
+		}
+	}
+
+	return dst
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 双线性采样，坐标以像素左上角为原点，超出边界取边缘像素
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func sampleBilinear(src *image.RGBA, x, y float64) (uint8, uint8, uint8, uint8) {
+	bounds := src.Bounds()
+
+	clamp := func(value, min, max int) int {
+		if value < min {
+			return min
+		}
+		if value > max {
+			return max
+		}
+		return value
+	}
+
+	x0, y0 := int(math.Floor(x)), int(math.Floor(y))
+	fx, fy := x-float64(x0), y-float64(y0)
+
+	x1 := clamp(x0+1, bounds.Min.X, bounds.Max.X-1)
+	y1 := clamp(y0+1, bounds.Min.Y, bounds.Max.Y-1)
+	x0 = clamp(x0, bounds.Min.X, bounds.Max.X-1)
+	y0 = clamp(y0, bounds.Min.Y, bounds.Max.Y-1)
+
+	p00 := src.PixOffset(x0, y0)
+	p10 := src.PixOffset(x1, y0)
+	p01 := src.PixOffset(x0, y1)
+	p11 := src.PixOffset(x1, y1)
+
+	var channels [4]uint8
+	for index := 0; index < 4; index++ {
+		top := float64(src.Pix[p00+index])*(1-fx) + float64(src.Pix[p10+index])*fx
+		bottom := float64(src.Pix[p01+index])*(1-fx) + float64(src.Pix[p11+index])*fx
+		channels[index] = uint8(top*(1-fy) + bottom*fy + 0.5)
+	}
+
+	return channels[0], channels[1], channels[2], channels[3]
+}
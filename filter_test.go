@@ -0,0 +1,95 @@
+package gcaptcha
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 测试用的棋盘格图片，原点不为0以覆盖子图坐标
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func newTestFilterImage() *image.RGBA {
+	img := image.NewRGBA(image.Rect(10, 5, 90, 45))
+	for y := img.Rect.Min.Y; y < img.Rect.Max.Y; y++ {
+		for x := img.Rect.Min.X; x < img.Rect.Max.X; x++ {
+			if (x/4+y/4)%2 == 0 {
+				img.SetRGBA(x, y, color.RGBA{30, 60, 90, 255})
+			} else {
+				img.SetRGBA(x, y, color.RGBA{240, 220, 200, 255})
+			}
+		}
+	}
+
+	return img
+}
+
+func TestFilters(t *testing.T) {
+	tests := []struct {
+		name   string
+		zero   IFilter
+		filter IFilter
+	}{
+		{"wave", WaveFilter{}, WaveFilter{Strength: 3}},
+		{"elastic", ElasticFilter{}, ElasticFilter{Strength: 3}},
+		{"swirl", SwirlFilter{}, SwirlFilter{Strength: 2}},
+		{"ripple", RippleFilter{}, RippleFilter{Strength: 3}},
+	}
+
+	for _, test := range tests {
+		src := newTestFilterImage()
+
+		if dst := test.zero.Filter(src, NewRandom(1)); !bytes.Equal(dst.Pix, newTestFilterImage().Pix) || dst.Rect != src.Rect {
+			t.Errorf("%s: strength 0 changed the image", test.name)
+		}
+
+		dst := test.filter.Filter(src, NewRandom(7))
+		if dst.Rect != src.Rect {
+			t.Errorf("%s: bounds %v, want %v", test.name, dst.Rect, src.Rect)
+		}
+
+		if bytes.Equal(dst.Pix, src.Pix) {
+			t.Errorf("%s: image unchanged", test.name)
+		}
+
+		if !bytes.Equal(src.Pix, newTestFilterImage().Pix) {
+			t.Errorf("%s: source image modified", test.name)
+		}
+
+		if again := test.filter.Filter(newTestFilterImage(), NewRandom(7)); !bytes.Equal(dst.Pix, again.Pix) {
+			t.Errorf("%s: same seed gave a different image", test.name)
+		}
+
+		if other := test.filter.Filter(newTestFilterImage(), NewRandom(8)); bytes.Equal(dst.Pix, other.Pix) {
+			t.Errorf("%s: different seeds gave the same image", test.name)
+		}
+	}
+}
+
+func TestRemapImageIdentity(t *testing.T) {
+	src := newTestFilterImage()
+	dst := remapImage(src, func(x, y float64) (float64, float64) {
+		return x, y
+	})
+
+	if dst.Rect != src.Rect || !bytes.Equal(dst.Pix, src.Pix) {
+		t.Error("identity mapping changed the image")
+	}
+}
+
+func TestApplyFilters(t *testing.T) {
+	src := newTestFilterImage()
+	if dst := applyFilters(src, []IFilter{nil, WaveFilter{}}, NewRandom(1)); dst != src {
+		t.Error("nil and zero strength filters replaced the image")
+	}
+
+	//相同随机数源依次执行，与逐个执行结果一致
+	filters := []IFilter{WaveFilter{Strength: 2}, SwirlFilter{Strength: 1}}
+	random := NewRandom(3)
+	want := filters[1].Filter(filters[0].Filter(newTestFilterImage(), random), random)
+
+	if dst := applyFilters(newTestFilterImage(), filters, NewRandom(3)); !bytes.Equal(dst.Pix, want.Pix) {
+		t.Error("applyFilters differs from applying each filter in order")
+	}
+}
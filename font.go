@@ -0,0 +1,209 @@
+package gcaptcha
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"unicode"
+)
+
+import (
+	"github.com/golang/freetype"
+	"github.com/golang/freetype/truetype"
+	"github.com/sanxia/glib"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+/* ================================================================================
+ * 字体注册表
+ * qq group: 582452342
+ * email   : 2091938785@qq.com
+ * author  : 美丽的地球啊 - mliu
+ * ================================================================================ */
+var (
+	ErrFontGlyph = errors.New("font: no glyph for rune")
+
+	defaultFontRegistry = NewFontRegistry()
+	defaultFontOnce     sync.Once
+	defaultFont         *truetype.Font
+	defaultFontErr      error
+)
+
+type (
+	FontRegistry struct {
+		fonts map[string]*truetype.Font
+		mutex sync.RWMutex
+	}
+
+	FontOption struct {
+		Path   string //字体路径或注册名，为空时使用内置默认字体
+		Weight int    //随机权重，小于等于0时按1计算
+	}
+
+	weightedFont struct {
+		font   *truetype.Font
+		weight int
+	}
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 初始化字体注册表，每个字体只解析一次，并发安全
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func NewFontRegistry() *FontRegistry {
+	return &FontRegistry{
+		fonts: make(map[string]*truetype.Font, 0),
+	}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取字体，fontPath为空时返回内置默认字体
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *FontRegistry) GetFont(fontPath string) (*truetype.Font, error) {
+	if fontPath == "" {
+		return DefaultFont()
+	}
+
+	s.mutex.RLock()
+	font, ok := s.fonts[fontPath]
+	s.mutex.RUnlock()
+
+	if ok {
+		return font, nil
+	}
+
+	fontBytes, err := ioutil.ReadFile(glib.GetAbsolutePath(fontPath))
+	if err != nil {
+		return nil, fmt.Errorf("font registry: load %s: %v", fontPath, err)
+	}
+
+	font, err = freetype.ParseFont(fontBytes)
+	if err != nil {
+		return nil, fmt.Errorf("font registry: parse %s: %v", fontPath, err)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	//并发加载时保留先写入的字体
+	if oldFont, ok := s.fonts[fontPath]; ok {
+		return oldFont, nil
+	}
+	s.fonts[fontPath] = font
+
+	return font, nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 注册字体数据，name可作为FontPath使用
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *FontRegistry) Register(name string, fontBytes []byte) error {
+	font, err := freetype.ParseFont(fontBytes)
+	if err != nil {
+		return fmt.Errorf("font registry: parse %s: %v", name, err)
+	}
+
+	s.mutex.Lock()
+	s.fonts[name] = font
+	s.mutex.Unlock()
+
+	return nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 从默认注册表获取字体
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func GetFont(fontPath string) (*truetype.Font, error) {
+	return defaultFontRegistry.GetFont(fontPath)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 向默认注册表注册字体数据，如 embed 的字体文件
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func RegisterFont(name string, fontBytes []byte) error {
+	return defaultFontRegistry.Register(name, fontBytes)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 内置默认字体 Go Regular（BSD协议），仅含拉丁、希腊和西里尔字符，
+ * 中文需要通过FontPath或Fonts指定中文字体，否则绘制时返回ErrFontGlyph
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func DefaultFont() (*truetype.Font, error) {
+	defaultFontOnce.Do(func() {
+		defaultFont, defaultFontErr = freetype.ParseFont(goregular.TTF)
+	})
+
+	return defaultFont, defaultFontErr
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 加载字体集合，options为空时使用fontPath单个字体
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func loadFonts(options []FontOption, fontPath string) ([]*weightedFont, error) {
+	if len(options) == 0 {
+		options = []FontOption{{Path: fontPath}}
+	}
+
+	fonts := make([]*weightedFont, 0, len(options))
+	for _, option := range options {
+		font, err := GetFont(option.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		weight := option.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+
+		fonts = append(fonts, &weightedFont{
+			font:   font,
+			weight: weight,
+		})
+	}
+
+	return fonts, nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 检查字体是否包含text中全部字符的字形，空白字符除外
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func checkFontGlyphs(font *truetype.Font, text string) error {
+	for _, char := range text {
+		if !unicode.IsSpace(char) && font.Index(char) == 0 {
+			return fmt.Errorf("%w: %q", ErrFontGlyph, char)
+		}
+	}
+
+	return nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 按权重随机选择字体，选中字体缺少该字形时依次回退到其它含有字形的字体，
+ * 全部字体都缺少时返回ErrFontGlyph
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func pickFont(random IRandom, fonts []*weightedFont, text rune) (*truetype.Font, error) {
+	totalWeight := 0
+	for _, font := range fonts {
+		totalWeight += font.weight
+	}
+
+	selectedIndex := 0
+	value := random.Intn(totalWeight)
+	for index, font := range fonts {
+		if value < font.weight {
+			selectedIndex = index
+			break
+		}
+		value -= font.weight
+	}
+
+	for i := 0; i < len(fonts); i++ {
+		font := fonts[(selectedIndex+i)%len(fonts)].font
+		if font.Index(text) != 0 {
+			return font, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %q", ErrFontGlyph, text)
+}
@@ -0,0 +1,75 @@
+package gcaptcha
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckFontGlyphs(t *testing.T) {
+	font, err := DefaultFont()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		text    string
+		wantErr bool
+	}{
+		{"", false},
+		{"abc XYZ 123", false},
+		{"αβγ жзи", false},
+		{"点击", true},
+		{"abc：", true},
+	}
+
+	for _, test := range tests {
+		err := checkFontGlyphs(font, test.text)
+		if (err != nil) != test.wantErr {
+			t.Errorf("checkFontGlyphs(%q) err = %v, want error %v", test.text, err, test.wantErr)
+		}
+
+		if err != nil && !errors.Is(err, ErrFontGlyph) {
+			t.Errorf("checkFontGlyphs(%q) err = %v, want ErrFontGlyph", test.text, err)
+		}
+	}
+}
+
+func TestPickFontMissingGlyph(t *testing.T) {
+	fonts, err := loadFonts(nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if font, err := pickFont(NewRandom(1), fonts, 'a'); err != nil || font == nil {
+		t.Fatalf("pickFont('a') = %v, %v", font, err)
+	}
+
+	if _, err := pickFont(NewRandom(1), fonts, '中'); !errors.Is(err, ErrFontGlyph) {
+		t.Fatalf("pickFont('中') err = %v, want ErrFontGlyph", err)
+	}
+}
+
+func TestTextImageMissingGlyph(t *testing.T) {
+	tests := []struct {
+		name  string
+		title string
+		texts []string
+	}{
+		{"title", "请输入", []string{"a", "b"}},
+		{"texts", "", []string{"甲", "乙"}},
+	}
+
+	for _, test := range tests {
+		img := NewTextImage(test.title, test.texts, 2)
+		img.SetOption(ImageOption{
+			CellWidth:  30,
+			CellHeight: 40,
+			FontSize:   20,
+			Random:     NewRandom(1),
+		})
+
+		if _, err := img.GetImage(); !errors.Is(err, ErrFontGlyph) {
+			t.Errorf("%s: GetImage err = %v, want ErrFontGlyph", test.name, err)
+		}
+	}
+}
@@ -1,10 +1,10 @@
 module github.com/sanxia/gcaptcha
 
-go 1.14
+go 1.16
 
 require (
 	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0
 	github.com/sanxia/glib v1.0.1
 	github.com/sanxia/gmusic v1.0.0
-	golang.org/x/image v0.0.0-20200430140353-33d19683fad8 // indirect
+	golang.org/x/image v0.0.0-20200430140353-33d19683fad8
 )
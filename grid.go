@@ -1,21 +1,16 @@
 package gcaptcha
 
 import (
-	"bytes"
 	"fmt"
 	"image"
 	"image/color"
 	"image/draw"
-	"image/png"
-	"io/ioutil"
-	"log"
-	"os"
 	"sort"
+	"strconv"
 )
 
 import (
 	"github.com/golang/freetype"
-	"github.com/golang/freetype/truetype"
 	"github.com/sanxia/glib"
 )
 
@@ -37,13 +32,26 @@ type (
 		Backgroud     string
 		FontPath      string
 		ImagePath     string
-		datas         []*GridItem       //外部数据源
-		itemMap       map[int]*GridItem //数据映射
-		cellMap       map[int]string    //格子图片文件名映射
+		Provider      ICellImageProvider //单元格图片源，为空时读取ImagePath目录
+		Random        IRandom            //随机数源，为空时使用默认源
+		IsSecure      bool               //安全模式，目标项目和单元格位置使用crypto/rand选择
+		Noises        []INoise           //干扰噪声层
+		Filters       []IFilter          //编码前对整张图片依次执行的扭曲滤镜
+		Output        OutputOption       //输出格式，照片网格使用jpeg可显著减小体积
+		Rows          int                //行数，默认按count每行3列计算
+		Columns       int                //列数，默认3
+		TargetCount   int                //目标图片数，默认3
+		DecoyCount    int                //每个干扰项目的图片数，默认2
+		datas         []*GridItem        //外部数据源
+		itemMap       map[int]*GridItem  //数据映射
+		cellMap       map[int]string     //格子图片文件名映射
+		selectedMap   map[int][]int      //每个项目选中的文件名索引，datas由多个实例共享，只读不写
 		width         int
 		height        int
 		targetIndex   int //当前目标项目索引
 		count         int
+		isGenerated   bool
+		generateErr   error
 	}
 
 	GridItem struct {
@@ -51,7 +59,7 @@ type (
 		Path           string
 		Words          []string
 		Filenames      []int
-		SelectedIndexs []int
+		SelectedIndexs []int //已不再写入，选中索引保存在各图片实例中
 	}
 )
 
@@ -62,25 +70,70 @@ func NewGridImage(count int, datas []*GridItem) *gridImage {
 	gridImage := new(gridImage)
 	gridImage.count = count
 	gridImage.datas = datas
+	gridImage.Columns = 3
+	gridImage.TargetCount = 3
+	gridImage.DecoyCount = 2
 	//gridImage.FontPath = "assets/font/华文仿宋.ttf"
 	//gridImage.ImagePath = "assets/img/verify"
 
-	//init
-	for _, item := range gridImage.datas {
-		item.SelectedIndexs = make([]int, 0)
+	gridImage.itemMap = make(map[int]*GridItem, 0)
+	gridImage.cellMap = make(map[int]string, 0)
+	gridImage.selectedMap = make(map[int][]int, 0)
+
+	return gridImage
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 生成目标项目、干扰项目和单元格，首次获取数据或图片时执行
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *gridImage) generate() error {
+	if s.isGenerated {
+		return s.generateErr
 	}
+	s.isGenerated = true
 
-	gridImage.targetIndex = glib.RandIntRange(0, len(gridImage.datas))
-	gridImage.itemMap = map[int]*GridItem{
-		gridImage.targetIndex: gridImage.datas[gridImage.targetIndex],
+	if s.Columns <= 0 {
+		s.Columns = 3
+	}
+
+	if s.Rows <= 0 {
+		s.Rows = (s.count + s.Columns - 1) / s.Columns
 	}
-	gridImage.cellMap = make(map[int]string, 0)
 
-	gridImage.generateItems(3)
-	gridImage.generateSelectedIndexs()
-	gridImage.generateCellIndexs()
+	s.count = s.Rows * s.Columns
 
-	return gridImage
+	if s.TargetCount <= 0 || s.TargetCount > s.count {
+		s.generateErr = fmt.Errorf("grid image: target count %d out of range 1-%d", s.TargetCount, s.count)
+		return s.generateErr
+	}
+
+	if s.DecoyCount <= 0 {
+		s.generateErr = fmt.Errorf("grid image: decoy count %d must be positive", s.DecoyCount)
+		return s.generateErr
+	}
+
+	//干扰图片数量和所需干扰项目数量
+	decoyCellCount := s.count - s.TargetCount
+	decoyItemCount := (decoyCellCount + s.DecoyCount - 1) / s.DecoyCount
+
+	if len(s.datas) < decoyItemCount+1 {
+		s.generateErr = fmt.Errorf("grid image: need %d items, got %d", decoyItemCount+1, len(s.datas))
+		return s.generateErr
+	}
+
+	s.targetIndex = randIntRange(s.answerRandom(), 0, len(s.datas))
+	s.itemMap[s.targetIndex] = s.datas[s.targetIndex]
+
+	s.generateItems(decoyItemCount)
+
+	if err := s.generateSelectedIndexs(decoyCellCount); err != nil {
+		s.generateErr = err
+		return s.generateErr
+	}
+
+	s.generateCellIndexs()
+
+	return nil
 }
 
 /* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
@@ -88,9 +141,13 @@ func NewGridImage(count int, datas []*GridItem) *gridImage {
  * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
 func (s *gridImage) GetData() []int {
 	cellIndexs := make([]int, 0)
+	if err := s.generate(); err != nil {
+		return cellIndexs
+	}
+
 	for k, v := range s.itemMap {
 		if k == s.targetIndex {
-			for _, selectedIndex := range v.SelectedIndexs {
+			for _, selectedIndex := range s.selectedMap[k] {
 				filename := fmt.Sprintf("%s/%d", v.Path, v.Filenames[selectedIndex])
 				for cellIndex, cellName := range s.cellMap {
 					if cellName == filename {
@@ -101,25 +158,157 @@ func (s *gridImage) GetData() []int {
 		}
 	}
 
+	sort.Ints(cellIndexs)
+
 	return cellIndexs
 }
 
 /* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
- * 获取图片数据
+ * 获取标题，包含目标项目名称
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *gridImage) GetTitle() string {
+	title := s.Title
+	if title == "" {
+		title = "找出所有的："
+	}
+
+	if err := s.generate(); err != nil {
+		return title
+	}
+
+	return title + s.itemMap[s.targetIndex].Title
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取答案单元格索引文字
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *gridImage) GetText() []string {
+	cellIndexs := s.GetData()
+	sort.Ints(cellIndexs)
+
+	texts := make([]string, 0, len(cellIndexs))
+	for _, cellIndex := range cellIndexs {
+		texts = append(texts, strconv.Itoa(cellIndex))
+	}
+
+	return texts
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 设置图片选项
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *gridImage) SetOption(option ImageOption) {
+	s.HeaderHeight = option.HeaderHeight
+	s.CellWidth = option.CellWidth
+	s.CellHeight = option.CellHeight
+	s.Gap = option.Gap
+	s.PaddingWidth = option.Padding
+	s.PaddingHeight = option.Padding
+	s.Backgroud = option.Backgroud
+	s.FontPath = option.FontPath
+	s.Random = option.Random
+	s.IsSecure = option.IsSecure
+	s.Noises = option.Noises
+	s.Filters = option.Filters
+	s.Output = option.Output
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取随机数源
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *gridImage) random() IRandom {
+	return getRandom(s.Random)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取答案选择随机数源
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *gridImage) answerRandom() IRandom {
+	return getAnswerRandom(s.Random, s.IsSecure)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 单元格答案与点选顺序无关
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *gridImage) getAnswerKind() string {
+	return answerKindUnordered
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取网格布局，与GetImage绘制使用相同的几何尺寸
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *gridImage) GetLayout() GridLayout {
+	s.generate()
+
+	return GridLayout{
+		Rows:          s.Rows,
+		Columns:       s.Columns,
+		CellWidth:     s.CellWidth,
+		CellHeight:    s.CellHeight,
+		Gap:           s.Gap,
+		PaddingWidth:  s.PaddingWidth,
+		PaddingHeight: s.PaddingHeight,
+		HeaderHeight:  s.HeaderHeight,
+	}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 校验图片像素坐标的点击，点中全部目标且无多点、无落空才算通过
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *gridImage) VerifyClicks(points []image.Point) bool {
+	cellIndexs, err := s.GetLayout().ClickIndexs(points)
+	if err != nil {
+		return false
+	}
+
+	targetIndexs := s.GetData()
+	if len(targetIndexs) == 0 || len(cellIndexs) != len(targetIndexs) {
+		return false
+	}
+
+	flags := make(map[int]bool, 0)
+	for _, targetIndex := range targetIndexs {
+		flags[targetIndex] = true
+	}
+
+	for _, cellIndex := range cellIndexs {
+		if !flags[cellIndex] {
+			return false
+		}
+	}
+
+	return true
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取图片数据，按Output选择的格式编码
  * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
 func (s *gridImage) GetImage() ([]byte, error) {
-	var buf bytes.Buffer
+	img, err := s.GetRawImage()
+	if err != nil {
+		return nil, err
+	}
+
+	return encodeImage(img, s.Output)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取未编码的图片
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *gridImage) GetRawImage() (image.Image, error) {
 	if s.Title == "" {
 		s.Title = "找出所有的："
 	}
-	rows, columns := 0, 0
-	headerHeight := s.HeaderHeight
-	width := s.CellWidth
-	height := s.CellHeight
-	gap := s.Gap
 
-	s.width = 3*(width+gap) + gap + (2 * s.PaddingWidth)
-	s.height = 3*(width+gap) + gap + headerHeight + (2 * s.PaddingHeight)
+	if err := s.generate(); err != nil {
+		return nil, err
+	}
+
+	layout := s.GetLayout()
+	size := layout.Size()
+
+	s.width = size.X
+	s.height = size.Y
 
 	//画布偏移点
 	offsetPoint := image.Point{s.PaddingWidth, s.PaddingHeight}
@@ -143,57 +332,35 @@ func (s *gridImage) GetImage() ([]byte, error) {
 	}
 
 	//标题
-	titleImage, _ := s.getTitleImage()
+	titleImage, err := s.getTitleImage()
+	if err != nil {
+		return nil, err
+	}
 	draw.Draw(graphics, titleImage.Bounds().Add(offsetPoint), titleImage, image.ZP, draw.Over)
 
 	//图片单元格
+	provider := s.Provider
+	if provider == nil {
+		provider = NewFileCellProvider(s.ImagePath)
+	}
+
 	for _, cellIndex := range keys {
-		fontPath := fmt.Sprintf("%s%s%s.png", s.ImagePath, string(os.PathSeparator), s.cellMap[cellIndex])
-		img, err := glib.GetImageFile(glib.GetAbsolutePath(fontPath))
+		img, err := provider.GetImage(s.cellMap[cellIndex])
 		if err != nil {
-			log.Printf("GetImageFile err: %v", err)
 			return nil, err
 		}
 
-		if cellIndex == 3 || cellIndex == 6 {
-			rows++
-			columns = 0
-		}
-
-		x := columns*(width+gap) + gap
-		y := rows*(height+gap) + gap + headerHeight
-		r := image.Rect(x, y, s.width, s.height).Add(offsetPoint)
-
+		r := layout.CellRect(cellIndex)
 		draw.Draw(graphics, r, img, img.Bounds().Min, draw.Over)
-
-		columns++
-	}
-
-	if err := png.Encode(&buf, graphics); err != nil {
-		return nil, err
 	}
 
-	return buf.Bytes(), nil
-}
+	//噪声
+	drawNoises(graphics, s.Noises, nil, s.random())
 
-/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
- * 获取字体
- * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
-func (s *gridImage) getFont(fontPath string) (*truetype.Font, error) {
-	absolutePath := glib.GetAbsolutePath(fontPath)
-
-	fontBytes, err := ioutil.ReadFile(absolutePath)
-	if err != nil {
-		return nil, err
-	}
-
-	font, err := freetype.ParseFont(fontBytes)
-	if err != nil {
-		return nil, err
-	}
-
-	return font, nil
+	//滤镜
+	graphics = applyFilters(graphics, s.Filters, s.random())
 
+	return graphics, nil
 }
 
 /* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
@@ -206,7 +373,16 @@ func (s *gridImage) getTitleImage() (image.Image, error) {
 	//draw.Draw(graphics, graphics.Bounds(), &image.Uniform{white}, image.ZP, draw.Src)
 	draw.Draw(graphics, graphics.Bounds(), image.Transparent, image.ZP, draw.Src)
 
-	font, _ := s.getFont(s.FontPath)
+	font, err := GetFont(s.FontPath)
+	if err != nil {
+		return nil, err
+	}
+
+	targetTitle := s.itemMap[s.targetIndex].Title
+	if err := checkFontGlyphs(font, s.Title+targetTitle); err != nil {
+		return nil, err
+	}
+
 	ctx := freetype.NewContext()
 	ctx.SetDPI(72)
 	ctx.SetFontSize(12)
@@ -233,7 +409,6 @@ func (s *gridImage) getTitleImage() (image.Image, error) {
 		pt.X += ctx.PointToFixed(space)
 	}
 
-	targetTitle := s.itemMap[s.targetIndex].Title
 	ctx.SetFontSize(16)
 	for _, s := range targetTitle {
 		_, err := ctx.DrawString(string(s), pt)
@@ -251,83 +426,98 @@ func (s *gridImage) getTitleImage() (image.Image, error) {
  * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
 func (s *gridImage) generateItems(count int) {
 	for count > 0 {
-		index := glib.RandIntRange(0, len(s.datas))
+		index := randIntRange(s.answerRandom(), 0, len(s.datas))
 		for {
 			if _, ok := s.itemMap[index]; !ok {
 				break
 			} else {
-				index = glib.RandIntRange(0, len(s.datas))
+				index = randIntRange(s.answerRandom(), 0, len(s.datas))
 			}
 		}
 
 		s.itemMap[index] = s.datas[index]
-		log.Printf("generateItems index: %d", index)
 		count--
 	}
-	log.Printf("generateItems s.itemMap: %v", s.itemMap)
-
 }
 
 /* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
  * 生成每个项目的选中索引集合
  * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
-func (s *gridImage) generateSelectedIndexs() {
-	for k, v := range s.itemMap {
-		filenameCount := 2
+func (s *gridImage) generateSelectedIndexs(decoyCellCount int) error {
+	for _, k := range s.getItemKeys() {
+		v := s.itemMap[k]
+		filenameCount := s.DecoyCount
 		if k == s.targetIndex {
-			filenameCount = 3
+			filenameCount = s.TargetCount
+		} else {
+			//最后一个干扰项目只取剩余数量
+			if filenameCount > decoyCellCount {
+				filenameCount = decoyCellCount
+			}
+			decoyCellCount -= filenameCount
+		}
+
+		if len(v.Filenames) < filenameCount {
+			return fmt.Errorf("grid image: item %s has %d images, need %d", v.Title, len(v.Filenames), filenameCount)
 		}
 
 		maps := make(map[int]int, 0)
 
 		for filenameCount > 0 {
-			index := glib.RandIntRange(0, len(v.Filenames))
+			index := randIntRange(s.answerRandom(), 0, len(v.Filenames))
 
 			for {
 				if _, ok := maps[index]; !ok {
 					break
 				} else {
-					index = glib.RandIntRange(0, len(v.Filenames))
+					index = randIntRange(s.answerRandom(), 0, len(v.Filenames))
 				}
 			}
 
 			maps[index] = index
 
 			//每个选中项的选中索引集合
-			v.SelectedIndexs = append(v.SelectedIndexs, index)
-
-			log.Printf("generateSelectedIndexs index: %d", index)
-			log.Printf("generateSelectedIndexs k:%d, v: %v", k, v)
+			s.selectedMap[k] = append(s.selectedMap[k], index)
 
 			filenameCount--
 		}
-
-		log.Printf("generateSelectedIndexs v.SelectedIndexs: %v", v.SelectedIndexs)
 	}
+
+	return nil
 }
 
 /* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
  * 生成单元格集合
  * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
 func (s *gridImage) generateCellIndexs() {
-	for _, v := range s.itemMap {
+	for _, k := range s.getItemKeys() {
+		v := s.itemMap[k]
 
 		//项目选中集合里的每个文件名, SelectedIndex对应着文件名映射
-		for _, selectedIndex := range v.SelectedIndexs {
-			index := glib.RandIntRange(0, s.count)
+		for _, selectedIndex := range s.selectedMap[k] {
+			index := randIntRange(s.answerRandom(), 0, s.count)
 			for {
 				if _, ok := s.cellMap[index]; !ok {
 					break
 				} else {
-					index = glib.RandIntRange(0, s.count)
+					index = randIntRange(s.answerRandom(), 0, s.count)
 				}
 			}
 			filename := fmt.Sprintf("%s/%d", v.Path, v.Filenames[selectedIndex])
 			s.cellMap[index] = filename
-
-			log.Printf("generateCellIndexs index: %d", index)
 		}
+	}
+}
 
-		log.Printf("generateCellIndexs s.cellMap: %v", s.cellMap)
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取有序的项目索引，保证相同随机数源生成相同结果
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *gridImage) getItemKeys() []int {
+	keys := make([]int, 0, len(s.itemMap))
+	for k := range s.itemMap {
+		keys = append(keys, k)
 	}
+	sort.Ints(keys)
+
+	return keys
 }
@@ -0,0 +1,134 @@
+package gcaptcha
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"strconv"
+)
+
+/* ================================================================================
+ * 网格布局
+ * qq group: 582452342
+ * email   : 2091938785@qq.com
+ * author  : 美丽的地球啊 - mliu
+ * ================================================================================ */
+var (
+	ErrGridClickEmpty = errors.New("grid layout: no click points")
+)
+
+type (
+	GridLayout struct {
+		Rows          int
+		Columns       int
+		CellWidth     int
+		CellHeight    int
+		Gap           int
+		PaddingWidth  int
+		PaddingHeight int
+		HeaderHeight  int
+	}
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取画布尺寸
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s GridLayout) Size() image.Point {
+	width := s.Columns*(s.CellWidth+s.Gap) + s.Gap + (2 * s.PaddingWidth)
+	height := s.Rows*(s.CellHeight+s.Gap) + s.Gap + s.HeaderHeight + (2 * s.PaddingHeight)
+
+	return image.Point{width, height}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取单元格在画布中的区域，布局无效或索引超出范围时返回空区域
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s GridLayout) CellRect(cellIndex int) image.Rectangle {
+	if s.Rows <= 0 || s.Columns <= 0 || s.CellWidth <= 0 || s.CellHeight <= 0 {
+		return image.Rectangle{}
+	}
+
+	if cellIndex < 0 || cellIndex >= s.Rows*s.Columns {
+		return image.Rectangle{}
+	}
+
+	rows, columns := cellIndex/s.Columns, cellIndex%s.Columns
+
+	x := columns*(s.CellWidth+s.Gap) + s.Gap + s.PaddingWidth
+	y := rows*(s.CellHeight+s.Gap) + s.Gap + s.HeaderHeight + s.PaddingHeight
+
+	return image.Rect(x, y, x+s.CellWidth, y+s.CellHeight)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 根据画布像素坐标获取单元格索引，落在间隙或格子外返回false
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s GridLayout) CellIndex(point image.Point) (int, bool) {
+	if s.Rows <= 0 || s.Columns <= 0 || s.CellWidth <= 0 || s.CellHeight <= 0 {
+		return 0, false
+	}
+
+	x := point.X - s.PaddingWidth - s.Gap
+	y := point.Y - s.PaddingHeight - s.HeaderHeight - s.Gap
+	if x < 0 || y < 0 {
+		return 0, false
+	}
+
+	columns, offsetX := x/(s.CellWidth+s.Gap), x%(s.CellWidth+s.Gap)
+	rows, offsetY := y/(s.CellHeight+s.Gap), y%(s.CellHeight+s.Gap)
+
+	if columns >= s.Columns || rows >= s.Rows {
+		return 0, false
+	}
+
+	if offsetX >= s.CellWidth || offsetY >= s.CellHeight {
+		return 0, false
+	}
+
+	return rows*s.Columns + columns, true
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 点击坐标集合转换为单元格索引集合，点击落空或重复点击同一格返回错误
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s GridLayout) ClickIndexs(points []image.Point) ([]int, error) {
+	if len(points) == 0 {
+		return nil, ErrGridClickEmpty
+	}
+
+	cellIndexs := make([]int, 0, len(points))
+	flags := make(map[int]bool, 0)
+
+	for _, point := range points {
+		cellIndex, ok := s.CellIndex(point)
+		if !ok {
+			return nil, fmt.Errorf("grid layout: click %v is outside any cell", point)
+		}
+
+		if flags[cellIndex] {
+			return nil, fmt.Errorf("grid layout: cell %d clicked more than once", cellIndex)
+		}
+		flags[cellIndex] = true
+
+		cellIndexs = append(cellIndexs, cellIndex)
+	}
+
+	return cellIndexs, nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 点击坐标集合转换为答案文字，可直接用于Captcha.Verify
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s GridLayout) ClickTexts(points []image.Point) ([]string, error) {
+	cellIndexs, err := s.ClickIndexs(points)
+	if err != nil {
+		return nil, err
+	}
+
+	texts := make([]string, 0, len(cellIndexs))
+	for _, cellIndex := range cellIndexs {
+		texts = append(texts, strconv.Itoa(cellIndex))
+	}
+
+	return texts, nil
+}
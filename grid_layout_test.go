@@ -0,0 +1,102 @@
+package gcaptcha
+
+import (
+	"image"
+	"reflect"
+	"testing"
+)
+
+var testGridLayout = GridLayout{
+	Rows:          2,
+	Columns:       3,
+	CellWidth:     50,
+	CellHeight:    40,
+	Gap:           2,
+	PaddingWidth:  5,
+	PaddingHeight: 4,
+	HeaderHeight:  20,
+}
+
+func TestGridLayoutCellRect(t *testing.T) {
+	tests := []struct {
+		name      string
+		layout    GridLayout
+		cellIndex int
+		want      image.Rectangle
+	}{
+		{"first", testGridLayout, 0, image.Rect(7, 26, 57, 66)},
+		{"first row last", testGridLayout, 2, image.Rect(111, 26, 161, 66)},
+		{"second row", testGridLayout, 3, image.Rect(7, 68, 57, 108)},
+		{"last", testGridLayout, 5, image.Rect(111, 68, 161, 108)},
+		{"negative index", testGridLayout, -1, image.Rectangle{}},
+		{"index out of range", testGridLayout, 6, image.Rectangle{}},
+		{"zero columns", GridLayout{Rows: 2, CellWidth: 50, CellHeight: 40}, 0, image.Rectangle{}},
+		{"zero rows", GridLayout{Columns: 3, CellWidth: 50, CellHeight: 40}, 0, image.Rectangle{}},
+		{"zero cell size", GridLayout{Rows: 2, Columns: 3}, 0, image.Rectangle{}},
+	}
+
+	for _, test := range tests {
+		if got := test.layout.CellRect(test.cellIndex); got != test.want {
+			t.Errorf("%s: CellRect(%d) = %v, want %v", test.name, test.cellIndex, got, test.want)
+		}
+	}
+}
+
+func TestGridLayoutCellIndex(t *testing.T) {
+	tests := []struct {
+		name      string
+		layout    GridLayout
+		point     image.Point
+		wantIndex int
+		wantOk    bool
+	}{
+		{"first cell corner", testGridLayout, image.Pt(7, 26), 0, true},
+		{"first cell last pixel", testGridLayout, image.Pt(56, 65), 0, true},
+		{"gap between columns", testGridLayout, image.Pt(57, 30), 0, false},
+		{"gap between rows", testGridLayout, image.Pt(30, 67), 0, false},
+		{"second row middle", testGridLayout, image.Pt(80, 90), 4, true},
+		{"last cell", testGridLayout, image.Pt(160, 107), 5, true},
+		{"header", testGridLayout, image.Pt(30, 10), 0, false},
+		{"padding", testGridLayout, image.Pt(3, 30), 0, false},
+		{"right of grid", testGridLayout, image.Pt(170, 30), 0, false},
+		{"below grid", testGridLayout, image.Pt(30, 120), 0, false},
+		{"zero columns", GridLayout{Rows: 2, CellWidth: 50, CellHeight: 40}, image.Pt(1, 1), 0, false},
+	}
+
+	for _, test := range tests {
+		index, ok := test.layout.CellIndex(test.point)
+		if index != test.wantIndex || ok != test.wantOk {
+			t.Errorf("%s: CellIndex(%v) = %d, %v, want %d, %v", test.name, test.point, index, ok, test.wantIndex, test.wantOk)
+		}
+	}
+}
+
+func TestGridLayoutCellRectRoundTrip(t *testing.T) {
+	for cellIndex := 0; cellIndex < testGridLayout.Rows*testGridLayout.Columns; cellIndex++ {
+		rect := testGridLayout.CellRect(cellIndex)
+		for _, point := range []image.Point{rect.Min, rect.Max.Sub(image.Pt(1, 1))} {
+			if index, ok := testGridLayout.CellIndex(point); !ok || index != cellIndex {
+				t.Errorf("cell %d: CellIndex(%v) = %d, %v", cellIndex, point, index, ok)
+			}
+		}
+	}
+}
+
+func TestGridLayoutClickTexts(t *testing.T) {
+	texts, err := testGridLayout.ClickTexts([]image.Point{{80, 90}, {7, 26}})
+	if err != nil || !reflect.DeepEqual(texts, []string{"4", "0"}) {
+		t.Errorf("ClickTexts = %v, %v", texts, err)
+	}
+
+	if _, err := testGridLayout.ClickTexts(nil); err != ErrGridClickEmpty {
+		t.Errorf("ClickTexts(nil) err = %v, want ErrGridClickEmpty", err)
+	}
+
+	if _, err := testGridLayout.ClickTexts([]image.Point{{7, 26}, {8, 27}}); err == nil {
+		t.Error("ClickTexts accepted the same cell twice")
+	}
+
+	if _, err := testGridLayout.ClickTexts([]image.Point{{57, 30}}); err == nil {
+		t.Error("ClickTexts accepted a click in the gap")
+	}
+}
@@ -0,0 +1,135 @@
+package gcaptcha
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 卡方统计量，expected为每个桶的期望次数
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func chiSquare(counts []int, expected float64) float64 {
+	var sum float64
+	for _, count := range counts {
+		diff := float64(count) - expected
+		sum += diff * diff / expected
+	}
+
+	return sum
+}
+
+func TestGridImageSecureCellDistribution(t *testing.T) {
+	const rounds = 3000
+
+	datas := make([]*GridItem, 0, 6)
+	for index := 0; index < 6; index++ {
+		datas = append(datas, &GridItem{
+			Title:     fmt.Sprintf("item%d", index),
+			Path:      fmt.Sprintf("item%d", index),
+			Filenames: []int{1, 2, 3, 4, 5},
+		})
+	}
+
+	counts := make([]int, 9)
+	for round := 0; round < rounds; round++ {
+		img := NewGridImage(9, datas)
+		img.IsSecure = true
+
+		cellIndexs := img.GetData()
+		if len(cellIndexs) != img.TargetCount {
+			t.Fatalf("round %d: got %d target cells, want %d", round, len(cellIndexs), img.TargetCount)
+		}
+
+		for _, cellIndex := range cellIndexs {
+			counts[cellIndex]++
+		}
+	}
+
+	//自由度8，p=0.0001的临界值约为31.8
+	expected := float64(rounds*3) / 9
+	if value := chiSquare(counts, expected); value > 40 {
+		t.Fatalf("target cells not uniform: counts %v, chi-square %.1f", counts, value)
+	}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 多个请求共用同一组GridItem，使用 go test -race 检测数据竞争
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func TestGridImageSharedItems(t *testing.T) {
+	const goroutines = 50
+
+	datas := make([]*GridItem, 0, 6)
+	for index := 0; index < 6; index++ {
+		datas = append(datas, &GridItem{
+			Title:     fmt.Sprintf("item%d", index),
+			Path:      fmt.Sprintf("item%d", index),
+			Filenames: []int{1, 2, 3, 4, 5},
+		})
+	}
+
+	getData := func(seed int64) ([]int, string) {
+		img := NewGridImage(9, datas)
+		img.Random = NewRandom(seed)
+
+		return img.GetData(), img.GetTitle()
+	}
+
+	//串行生成的期望结果
+	wantDatas := make([][]int, goroutines)
+	wantTitles := make([]string, goroutines)
+	for index := range wantDatas {
+		wantDatas[index], wantTitles[index] = getData(int64(index))
+	}
+
+	var wg sync.WaitGroup
+	gotDatas := make([][]int, goroutines)
+	gotTitles := make([]string, goroutines)
+	for index := 0; index < goroutines; index++ {
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+			gotDatas[index], gotTitles[index] = getData(int64(index))
+		}(index)
+	}
+	wg.Wait()
+
+	for index := range wantDatas {
+		if len(gotDatas[index]) != 3 || !reflect.DeepEqual(gotDatas[index], wantDatas[index]) || gotTitles[index] != wantTitles[index] {
+			t.Errorf("seed %d: got %v %q, want %v %q", index, gotDatas[index], gotTitles[index], wantDatas[index], wantTitles[index])
+		}
+	}
+
+	for _, item := range datas {
+		if item.SelectedIndexs != nil {
+			t.Errorf("item %s modified: SelectedIndexs %v", item.Title, item.SelectedIndexs)
+		}
+	}
+}
+
+func TestTextImageSecureCellDistribution(t *testing.T) {
+	const rounds = 3000
+
+	texts := []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"}
+	positions := make(map[string]int, len(texts))
+	for index, text := range texts {
+		positions[text] = index
+	}
+
+	counts := make([]int, len(texts))
+	for round := 0; round < rounds; round++ {
+		img := NewTextImage("", texts, 4)
+		img.SetOption(ImageOption{IsSecure: true})
+
+		for _, text := range img.GetText() {
+			counts[positions[text]]++
+		}
+	}
+
+	//自由度9，p=0.0001的临界值约为33.7
+	expected := float64(rounds*4) / float64(len(texts))
+	if value := chiSquare(counts, expected); value > 40 {
+		t.Fatalf("target cells not uniform: counts %v, chi-square %.1f", counts, value)
+	}
+}
@@ -0,0 +1,204 @@
+package httpcaptcha
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+import (
+	"github.com/sanxia/gcaptcha"
+)
+
+/* ================================================================================
+ * 验证码http处理器
+ * qq group: 582452342
+ * email   : 2091938785@qq.com
+ * author  : 美丽的地球啊 - mliu
+ * ================================================================================ */
+const (
+	maxVerifyBodySize = 64 << 10 //校验请求体上限，足够容纳滑块轨迹
+)
+
+type (
+	ImageFactory func() (gcaptcha.IImage, error)
+
+	Handler struct {
+		captcha *gcaptcha.Captcha
+		factory ImageFactory
+	}
+
+	challengeResponse struct {
+		Id          string `json:"id"`
+		Image       string `json:"image"`       //base64图片数据
+		ContentType string `json:"contentType"` //图片类型，如 image/png
+		Title       string `json:"title"`
+	}
+
+	verifyRequest struct {
+		Id      string   `json:"id"`
+		Answers []string `json:"answers"`
+	}
+
+	verifyResponse struct {
+		Success bool `json:"success"`
+	}
+
+	errorResponse struct {
+		Error string `json:"error"`
+	}
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 初始化处理器，factory每次请求生成一个新的验证码图片
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func NewHandler(captcha *gcaptcha.Captcha, factory ImageFactory) *Handler {
+	return &Handler{
+		captcha: captcha,
+		factory: factory,
+	}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 生成验证码，返回标识、base64图片、图片类型和提示标题，
+ * 图片未实现ITitleImage时不返回标题
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *Handler) Challenge() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodPost {
+			s.writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+
+		img, err := s.factory()
+		if err != nil {
+			log.Printf("httpcaptcha factory err: %v", err)
+			s.writeError(w, http.StatusInternalServerError, "captcha generate failed")
+			return
+		}
+
+		id, imageData, err := s.captcha.Generate(img)
+		if err != nil {
+			log.Printf("httpcaptcha generate err: %v", err)
+			s.writeError(w, http.StatusInternalServerError, "captcha generate failed")
+			return
+		}
+
+		response := &challengeResponse{
+			Id:          id,
+			Image:       base64.StdEncoding.EncodeToString(imageData),
+			ContentType: http.DetectContentType(imageData),
+		}
+
+		if titleImage, ok := img.(gcaptcha.ITitleImage); ok {
+			response.Title = titleImage.GetTitle()
+		}
+
+		setNoCache(w)
+		s.writeJson(w, http.StatusOK, response)
+	})
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 根据查询参数id输出图片
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *Handler) Image() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			s.writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+
+		imageData, err := s.captcha.GetImage(r.URL.Query().Get("id"))
+		if err != nil {
+			s.writeError(w, http.StatusNotFound, "captcha not found")
+			return
+		}
+
+		setNoCache(w)
+		w.Header().Set("Content-Type", http.DetectContentType(imageData))
+		w.WriteHeader(http.StatusOK)
+
+		if r.Method == http.MethodGet {
+			w.Write(imageData)
+		}
+	})
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 校验答案，支持json请求体 {"id":"", "answers":[]}
+ * 或表单参数 id, answer（可重复）
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *Handler) Verify() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			s.writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+
+		request, err := parseVerifyRequest(w, r)
+		if err != nil {
+			s.writeError(w, http.StatusBadRequest, "invalid request")
+			return
+		}
+
+		setNoCache(w)
+		s.writeJson(w, http.StatusOK, &verifyResponse{
+			Success: s.captcha.Verify(request.Id, request.Answers),
+		})
+	})
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 解析校验请求，请求体超过maxVerifyBodySize时返回错误
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func parseVerifyRequest(w http.ResponseWriter, r *http.Request) (*verifyRequest, error) {
+	request := new(verifyRequest)
+	r.Body = http.MaxBytesReader(w, r.Body, maxVerifyBodySize)
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/json" {
+		if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+			return nil, err
+		}
+
+		return request, nil
+	}
+
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+
+	request.Id = r.PostForm.Get("id")
+	for _, answer := range r.PostForm["answer"] {
+		for _, text := range strings.Split(answer, ",") {
+			request.Answers = append(request.Answers, text)
+		}
+	}
+
+	return request, nil
+}
+
+func setNoCache(w http.ResponseWriter) {
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Header().Set("Pragma", "no-cache")
+	w.Header().Set("Expires", "0")
+}
+
+func (s *Handler) writeJson(w http.ResponseWriter, statusCode int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(statusCode)
+
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		log.Printf("httpcaptcha write json err: %v", err)
+	}
+}
+
+func (s *Handler) writeError(w http.ResponseWriter, statusCode int, message string) {
+	s.writeJson(w, statusCode, &errorResponse{
+		Error: message,
+	})
+}
@@ -0,0 +1,214 @@
+package httpcaptcha
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/sanxia/gcaptcha"
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 生成测试处理器，answers返回最近一次生成的答案
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func newTestHandler(t *testing.T) (*Handler, func() []string) {
+	store := gcaptcha.NewMemoryStore(time.Minute)
+	t.Cleanup(store.Close)
+
+	var img gcaptcha.IImage
+	handler := NewHandler(gcaptcha.NewCaptcha(store, time.Minute), func() (gcaptcha.IImage, error) {
+		img = gcaptcha.NewTextImage("abc", []string{"a", "b", "c", "d", "e", "f"}, 4)
+		img.SetOption(gcaptcha.ImageOption{
+			CellWidth:  30,
+			CellHeight: 40,
+			FontSize:   20,
+		})
+
+		return img, nil
+	})
+
+	return handler, func() []string {
+		return img.GetText()
+	}
+}
+
+func getChallenge(t *testing.T, handler *Handler) *challengeResponse {
+	recorder := httptest.NewRecorder()
+	handler.Challenge().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/challenge", nil))
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("challenge status = %d, body %s", recorder.Code, recorder.Body.String())
+	}
+
+	response := new(challengeResponse)
+	if err := json.Unmarshal(recorder.Body.Bytes(), response); err != nil {
+		t.Fatalf("challenge body: %v", err)
+	}
+
+	return response
+}
+
+func TestChallenge(t *testing.T) {
+	handler, _ := newTestHandler(t)
+
+	response := getChallenge(t, handler)
+	if response.Id == "" {
+		t.Error("challenge id is empty")
+	}
+
+	if response.ContentType != "image/png" {
+		t.Errorf("challenge contentType = %q, want image/png", response.ContentType)
+	}
+
+	if response.Title != "abc" {
+		t.Errorf("challenge title = %q, want abc", response.Title)
+	}
+
+	imageData, err := base64.StdEncoding.DecodeString(response.Image)
+	if err != nil || http.DetectContentType(imageData) != "image/png" {
+		t.Errorf("challenge image is not base64 png: %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.Challenge().ServeHTTP(recorder, httptest.NewRequest(http.MethodDelete, "/challenge", nil))
+	if recorder.Code != http.StatusMethodNotAllowed {
+		t.Errorf("DELETE challenge status = %d, want %d", recorder.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestImage(t *testing.T) {
+	handler, _ := newTestHandler(t)
+	response := getChallenge(t, handler)
+
+	tests := []struct {
+		name        string
+		method      string
+		id          string
+		wantCode    int
+		wantBodyLen bool
+	}{
+		{"get", http.MethodGet, response.Id, http.StatusOK, true},
+		{"head", http.MethodHead, response.Id, http.StatusOK, false},
+		{"unknown id", http.MethodGet, "missing", http.StatusNotFound, true},
+		{"post", http.MethodPost, response.Id, http.StatusMethodNotAllowed, true},
+	}
+
+	for _, test := range tests {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(test.method, "/image?id="+url.QueryEscape(test.id), nil)
+		handler.Image().ServeHTTP(recorder, request)
+
+		if recorder.Code != test.wantCode {
+			t.Errorf("%s: status = %d, want %d", test.name, recorder.Code, test.wantCode)
+		}
+
+		if (recorder.Body.Len() > 0) != test.wantBodyLen {
+			t.Errorf("%s: body length = %d", test.name, recorder.Body.Len())
+		}
+
+		if test.wantCode == http.StatusOK && recorder.Header().Get("Content-Type") != "image/png" {
+			t.Errorf("%s: Content-Type = %q", test.name, recorder.Header().Get("Content-Type"))
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	jsonBody := func(id string, answers []string) (string, string) {
+		data, _ := json.Marshal(&verifyRequest{Id: id, Answers: answers})
+		return "application/json", string(data)
+	}
+
+	formBody := func(id string, answers []string) (string, string) {
+		values := url.Values{"id": {id}, "answer": {strings.Join(answers, ",")}}
+		return "application/x-www-form-urlencoded", values.Encode()
+	}
+
+	reverse := func(answers []string) []string {
+		results := make([]string, 0, len(answers))
+		for index := len(answers) - 1; index >= 0; index-- {
+			results = append(results, answers[index])
+		}
+		return results
+	}
+
+	tests := []struct {
+		name        string
+		body        func(id string, answers []string) (string, string)
+		answers     func([]string) []string
+		wantSuccess bool
+	}{
+		{"json correct", jsonBody, func(answers []string) []string { return answers }, true},
+		{"json wrong", jsonBody, reverse, false},
+		{"form correct", formBody, func(answers []string) []string { return answers }, true},
+		{"form wrong", formBody, reverse, false},
+	}
+
+	for _, test := range tests {
+		handler, getAnswers := newTestHandler(t)
+		response := getChallenge(t, handler)
+
+		contentType, body := test.body(response.Id, test.answers(getAnswers()))
+
+		//第二次提交同一验证码必须失败
+		for round, wantSuccess := range []bool{test.wantSuccess, false} {
+			request := httptest.NewRequest(http.MethodPost, "/verify", strings.NewReader(body))
+			request.Header.Set("Content-Type", contentType)
+
+			recorder := httptest.NewRecorder()
+			handler.Verify().ServeHTTP(recorder, request)
+
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("%s: status = %d, body %s", test.name, recorder.Code, recorder.Body.String())
+			}
+
+			result := new(verifyResponse)
+			if err := json.Unmarshal(recorder.Body.Bytes(), result); err != nil {
+				t.Fatalf("%s: body: %v", test.name, err)
+			}
+
+			if result.Success != wantSuccess {
+				t.Errorf("%s round %d: success = %v, want %v", test.name, round, result.Success, wantSuccess)
+			}
+		}
+	}
+}
+
+func TestVerifyBadRequest(t *testing.T) {
+	handler, _ := newTestHandler(t)
+
+	tests := []struct {
+		name        string
+		method      string
+		contentType string
+		body        string
+		wantCode    int
+	}{
+		{"get", http.MethodGet, "", "", http.StatusMethodNotAllowed},
+		{"invalid json", http.MethodPost, "application/json", "{", http.StatusBadRequest},
+		{"json too large", http.MethodPost, "application/json",
+			`{"id":"` + strings.Repeat("a", maxVerifyBodySize) + `"}`, http.StatusBadRequest},
+		{"form too large", http.MethodPost, "application/x-www-form-urlencoded",
+			"id=" + strings.Repeat("a", maxVerifyBodySize), http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		request := httptest.NewRequest(test.method, "/verify", strings.NewReader(test.body))
+		if test.contentType != "" {
+			request.Header.Set("Content-Type", test.contentType)
+		}
+
+		recorder := httptest.NewRecorder()
+		handler.Verify().ServeHTTP(recorder, request)
+
+		if recorder.Code != test.wantCode {
+			t.Errorf("%s: status = %d, want %d", test.name, recorder.Code, test.wantCode)
+		}
+	}
+}
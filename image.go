@@ -1,11 +1,22 @@
 package gcaptcha
 
+import (
+	"image"
+	"image/png"
+)
+
 /* ================================================================================
  * 五线谱图片
  * qq group: 582452342
  * email   : 2091938785@qq.com
  * author  : 美丽的地球啊 - mliu
  * ================================================================================ */
+const (
+	ImageFormatPng  = "png"
+	ImageFormatJpeg = "jpeg" //不支持透明，透明区域输出为黑色
+	ImageFormatGif  = "gif"  //多帧动画，仅NewTextImage支持，其它图片返回ErrImageFormat
+)
+
 type (
 	IImage interface {
 		GetText() []string
@@ -13,6 +24,16 @@ type (
 		SetOption(ImageOption)
 	}
 
+	//带提示标题的图片，如 请依次点击 'A' 'B'
+	ITitleImage interface {
+		GetTitle() string
+	}
+
+	//获取合成后未编码的图片，可嵌入其它图片或用于测试
+	IRawImage interface {
+		GetRawImage() (image.Image, error)
+	}
+
 	ImageOption struct {
 		HeaderHeight int
 		CellWidth    int
@@ -22,5 +43,20 @@ type (
 		Backgroud    string
 		FontPath     string
 		FontSize     float64
+		Fonts        []FontOption   //文字字体集合，每个字形按权重随机选择字体，为空时使用FontPath
+		Transform    GlyphTransform //字形随机旋转、缩放和错切
+		Noises       []INoise       //干扰噪声层，文字图片的曲线会穿过字形
+		Filters      []IFilter      //编码前对整张图片依次执行的扭曲滤镜
+		Random       IRandom        //随机数源，为空时使用默认源，固定种子可生成可复现的图片
+		IsSecure     bool           //安全模式，答案选择使用crypto/rand，干扰效果仍使用Random
+		Output       OutputOption   //输出格式
+	}
+
+	OutputOption struct {
+		Format         string               //图片格式png、jpeg或gif（仅文字图片），默认png，其它返回ErrImageFormat
+		PngCompression png.CompressionLevel //png压缩级别，默认png.DefaultCompression
+		JpegQuality    int                  //jpeg质量1-100，默认80
+		FrameCount     int                  //gif帧数，默认4
+		FrameDelay     int                  //gif帧间隔（1/100秒），默认50
 	}
 )
@@ -0,0 +1,167 @@
+package gcaptcha
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/* ================================================================================
+ * 网格图片数据清单
+ * qq group: 582452342
+ * email   : 2091938785@qq.com
+ * author  : 美丽的地球啊 - mliu
+ * ================================================================================ */
+var (
+	ErrGridManifestEmpty = errors.New("grid manifest: no categories")
+)
+
+type (
+	GridManifest struct {
+		Categories []GridCategory `json:"categories"`
+	}
+
+	GridCategory struct {
+		Title     string   `json:"title"`
+		Path      string   `json:"path"`
+		Words     []string `json:"words"`
+		Filenames []int    `json:"filenames"`
+	}
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 读取json清单并校验图片，category.path相对于清单文件所在目录
+ * 磁盘目录可使用 os.DirFS 传入，minCount为每个项目至少需要的图片数，
+ * 同时返回以清单目录为根的图片源，渲染时赋给gridImage.Provider
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func LoadGridManifest(fsys fs.FS, filename string, minCount int) ([]*GridItem, ICellImageProvider, error) {
+	manifestData, err := fs.ReadFile(fsys, filename)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var manifest GridManifest
+	if err := json.Unmarshal(manifestData, &manifest); err != nil {
+		return nil, nil, fmt.Errorf("grid manifest: parse %s: %v", filename, err)
+	}
+
+	provider := NewFSCellProvider(fsys, path.Dir(filename))
+
+	items, err := manifest.GetItems(provider, minCount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return items, provider, nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 按目录自动发现，root下每个子目录为一个项目，目录名为标题，
+ * 目录内 数字.png 为图片，同时返回以root为根的图片源
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func DiscoverGridItems(fsys fs.FS, root string, minCount int) ([]*GridItem, ICellImageProvider, error) {
+	dirEntries, err := fs.ReadDir(fsys, root)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	manifest := GridManifest{
+		Categories: make([]GridCategory, 0),
+	}
+
+	for _, dirEntry := range dirEntries {
+		if !dirEntry.IsDir() || strings.HasPrefix(dirEntry.Name(), ".") {
+			continue
+		}
+
+		fileEntries, err := fs.ReadDir(fsys, path.Join(root, dirEntry.Name()))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		category := GridCategory{
+			Title:     dirEntry.Name(),
+			Path:      dirEntry.Name(),
+			Filenames: make([]int, 0),
+		}
+
+		for _, fileEntry := range fileEntries {
+			if fileEntry.IsDir() || path.Ext(fileEntry.Name()) != ".png" {
+				continue
+			}
+
+			filename, err := strconv.Atoi(strings.TrimSuffix(fileEntry.Name(), ".png"))
+			if err != nil {
+				continue
+			}
+
+			category.Filenames = append(category.Filenames, filename)
+		}
+
+		sort.Ints(category.Filenames)
+		manifest.Categories = append(manifest.Categories, category)
+	}
+
+	provider := NewFSCellProvider(fsys, root)
+
+	items, err := manifest.GetItems(provider, minCount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return items, provider, nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 校验清单并生成网格项目，每张图片都必须存在且可以解码
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s GridManifest) GetItems(provider ICellImageProvider, minCount int) ([]*GridItem, error) {
+	if len(s.Categories) == 0 {
+		return nil, ErrGridManifestEmpty
+	}
+
+	items := make([]*GridItem, 0, len(s.Categories))
+	paths := make(map[string]bool, 0)
+
+	for _, category := range s.Categories {
+		if category.Title == "" || category.Path == "" {
+			return nil, fmt.Errorf("grid manifest: category %q requires title and path", category.Path)
+		}
+
+		if paths[category.Path] {
+			return nil, fmt.Errorf("grid manifest: duplicate category path %s", category.Path)
+		}
+		paths[category.Path] = true
+
+		if len(category.Filenames) < minCount {
+			return nil, fmt.Errorf("grid manifest: category %s has %d images, need %d", category.Path, len(category.Filenames), minCount)
+		}
+
+		filenames := make(map[int]bool, 0)
+		for _, filename := range category.Filenames {
+			if filenames[filename] {
+				return nil, fmt.Errorf("grid manifest: category %s has duplicate image %d", category.Path, filename)
+			}
+			filenames[filename] = true
+
+			name := fmt.Sprintf("%s/%d", category.Path, filename)
+			if _, err := provider.GetImage(name); err != nil {
+				return nil, fmt.Errorf("grid manifest: image %s: %v", name, err)
+			}
+		}
+
+		items = append(items, &GridItem{
+			Title:     category.Title,
+			Path:      category.Path,
+			Words:     append([]string(nil), category.Words...),
+			Filenames: append([]int(nil), category.Filenames...),
+		})
+	}
+
+	return items, nil
+}
@@ -0,0 +1,177 @@
+package gcaptcha
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"io/fs"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 测试用的png文件
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func newTestPngFile(t *testing.T) *fstest.MapFile {
+	var imageBuffer bytes.Buffer
+	if err := png.Encode(&imageBuffer, image.NewRGBA(image.Rect(0, 0, 8, 8))); err != nil {
+		t.Fatal(err)
+	}
+
+	return &fstest.MapFile{Data: imageBuffer.Bytes()}
+}
+
+func TestLoadGridManifest(t *testing.T) {
+	pngFile := newTestPngFile(t)
+	manifest := func(text string) *fstest.MapFile {
+		return &fstest.MapFile{Data: []byte(text)}
+	}
+
+	tests := []struct {
+		name    string
+		fsys    fstest.MapFS
+		wantErr string
+	}{
+		{"missing file", fstest.MapFS{}, "file does not exist"},
+		{"malformed json", fstest.MapFS{"grid/manifest.json": manifest(`{"categories": [`)}, "parse"},
+		{"no categories", fstest.MapFS{"grid/manifest.json": manifest(`{"categories": []}`)}, ErrGridManifestEmpty.Error()},
+		{"missing title", fstest.MapFS{
+			"grid/manifest.json": manifest(`{"categories": [{"path": "cat", "filenames": [1]}]}`),
+			"grid/cat/1.png":     pngFile,
+		}, "requires title and path"},
+		{"missing image", fstest.MapFS{
+			"grid/manifest.json": manifest(`{"categories": [{"title": "cat", "path": "cat", "filenames": [1, 2]}]}`),
+			"grid/cat/1.png":     pngFile,
+		}, "image cat/2"},
+		{"undecodable image", fstest.MapFS{
+			"grid/manifest.json": manifest(`{"categories": [{"title": "cat", "path": "cat", "filenames": [1, 2]}]}`),
+			"grid/cat/1.png":     pngFile,
+			"grid/cat/2.png":     &fstest.MapFile{Data: []byte("not a png")},
+		}, "image cat/2: image: unknown format"},
+		{"min count not met", fstest.MapFS{
+			"grid/manifest.json": manifest(`{"categories": [{"title": "cat", "path": "cat", "filenames": [1]}]}`),
+			"grid/cat/1.png":     pngFile,
+		}, "has 1 images, need 2"},
+		{"duplicate path", fstest.MapFS{
+			"grid/manifest.json": manifest(`{"categories": [
+				{"title": "cat", "path": "cat", "filenames": [1, 2]},
+				{"title": "kitten", "path": "cat", "filenames": [1, 2]}
+			]}`),
+			"grid/cat/1.png": pngFile,
+			"grid/cat/2.png": pngFile,
+		}, "duplicate category path cat"},
+		{"duplicate image", fstest.MapFS{
+			"grid/manifest.json": manifest(`{"categories": [{"title": "cat", "path": "cat", "filenames": [1, 1]}]}`),
+			"grid/cat/1.png":     pngFile,
+		}, "duplicate image 1"},
+	}
+
+	for _, test := range tests {
+		items, provider, err := LoadGridManifest(test.fsys, "grid/manifest.json", 2)
+		if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+			t.Errorf("%s: err = %v, want containing %q", test.name, err, test.wantErr)
+		}
+
+		if items != nil || provider != nil {
+			t.Errorf("%s: got items %v and provider %v with an error", test.name, items, provider)
+		}
+	}
+
+	if _, _, err := LoadGridManifest(fstest.MapFS{}, "manifest.json", 1); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("missing file: err = %v, want fs.ErrNotExist", err)
+	}
+}
+
+func TestLoadGridManifestRender(t *testing.T) {
+	pngFile := newTestPngFile(t)
+	fsys := fstest.MapFS{
+		"grid/manifest.json": &fstest.MapFile{Data: []byte(`{"categories": [
+			{"title": "cat", "path": "cat", "words": ["kitten"], "filenames": [1, 2]},
+			{"title": "dog", "path": "animals/dog", "filenames": [3, 4]}
+		]}`)},
+		"grid/cat/1.png":         pngFile,
+		"grid/cat/2.png":         pngFile,
+		"grid/animals/dog/3.png": pngFile,
+		"grid/animals/dog/4.png": pngFile,
+	}
+
+	items, provider, err := LoadGridManifest(fsys, "grid/manifest.json", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []*GridItem{
+		{Title: "cat", Path: "cat", Words: []string{"kitten"}, Filenames: []int{1, 2}},
+		{Title: "dog", Path: "animals/dog", Filenames: []int{3, 4}},
+	}
+	if !reflect.DeepEqual(items, want) {
+		t.Fatalf("items %+v, want %+v", items, want)
+	}
+
+	//返回的图片源以清单目录为根，可直接用于渲染
+	img := NewGridImage(4, items)
+	img.Title = "Find: "
+	img.Columns = 2
+	img.TargetCount = 2
+	img.DecoyCount = 2
+	img.CellWidth = 8
+	img.CellHeight = 8
+	img.Provider = provider
+	img.Random = NewRandom(1)
+
+	if _, err := img.GetImage(); err != nil {
+		t.Fatalf("GetImage with manifest provider: %v", err)
+	}
+}
+
+func TestDiscoverGridItems(t *testing.T) {
+	pngFile := newTestPngFile(t)
+	fsys := fstest.MapFS{
+		"grid/cat/10.png":     pngFile,
+		"grid/cat/2.png":      pngFile,
+		"grid/cat/cover.png":  pngFile,
+		"grid/cat/notes.txt":  &fstest.MapFile{Data: []byte("notes")},
+		"grid/dog/1.png":      pngFile,
+		"grid/dog/3.png":      pngFile,
+		"grid/.cache/1.png":   &fstest.MapFile{Data: []byte("not a png")},
+		"grid/readme.md":      &fstest.MapFile{Data: []byte("readme")},
+		"other/bird/1.png":    &fstest.MapFile{Data: []byte("not a png")},
+		"grid/dog/sub/10.png": pngFile,
+	}
+
+	items, provider, err := DiscoverGridItems(fsys, "grid", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []*GridItem{
+		{Title: "cat", Path: "cat", Filenames: []int{2, 10}},
+		{Title: "dog", Path: "dog", Filenames: []int{1, 3}},
+	}
+	if !reflect.DeepEqual(items, want) {
+		t.Fatalf("items %+v, want %+v", items, want)
+	}
+
+	if _, err := provider.GetImage("cat/10"); err != nil {
+		t.Errorf("provider GetImage: %v", err)
+	}
+
+	if _, _, err := DiscoverGridItems(fsys, "grid", 3); err == nil {
+		t.Error("min count 3: want error")
+	}
+
+	if _, _, err := DiscoverGridItems(fsys, "other", 1); err == nil {
+		t.Error("undecodable image: want error")
+	}
+
+	if _, _, err := DiscoverGridItems(fsys, "missing", 1); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("missing root: err = %v, want fs.ErrNotExist", err)
+	}
+
+	if _, _, err := DiscoverGridItems(fstest.MapFS{"grid/readme.md": &fstest.MapFile{}}, "grid", 1); err != ErrGridManifestEmpty {
+		t.Errorf("no categories: err = %v, want ErrGridManifestEmpty", err)
+	}
+}
@@ -0,0 +1,390 @@
+package gcaptcha
+
+import (
+	"errors"
+	"image"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+/* ================================================================================
+ * 算术表达式图片
+ * qq group: 582452342
+ * email   : 2091938785@qq.com
+ * author  : 美丽的地球啊 - mliu
+ * ================================================================================ */
+var (
+	ErrMathOperator   = errors.New("math image: unsupported operator")
+	ErrMathExpression = errors.New("math image: no non-negative integer expression in operand range")
+
+	mathOperatorTexts = map[string]string{
+		"+": "+",
+		"-": "-",
+		"*": "×",
+		"/": "÷",
+	}
+
+	mathChineseOperatorTexts = map[string]string{
+		"+": "加",
+		"-": "减",
+		"*": "乘",
+		"/": "除以",
+	}
+
+	chineseDigits = []string{"零", "一", "二", "三", "四", "五", "六", "七", "八", "九"}
+	chineseUnits  = []string{"", "十", "百", "千"}
+)
+
+const (
+	mathRetryCount   = 100
+	mathOperandLimit = 1000000            //操作数上限，限制除数枚举的开销
+	mathMaxInt       = int(^uint(0) >> 1) //乘积或和超过时重新生成
+)
+
+type (
+	mathImage struct {
+		textImage   *textImage
+		option      MathOption
+		texts       []string //表达式字符
+		answer      int
+		isGenerated bool
+		generateErr error
+	}
+
+	MathOption struct {
+		Operators    []string //可选运算符 + - * /，默认 + -
+		OperandMin   int      //操作数范围，含两端，默认 0-9，最大1000000
+		OperandMax   int
+		OperandCount int  //操作数个数，默认2
+		IsChinese    bool //使用中文数字和运算符，如 七加三等于？，需要通过FontPath指定中文字体
+	}
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 初始化算术表达式图，乘除优先于加减，结果保证为非负整数
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func NewMathImage(title string, option MathOption) IImage {
+	mathImage := &mathImage{
+		textImage: NewTextImage(title, nil, 0).(*textImage),
+		option:    option,
+	}
+
+	if len(mathImage.option.Operators) == 0 {
+		mathImage.option.Operators = []string{"+", "-"}
+	}
+
+	if mathImage.option.OperandMin < 0 {
+		mathImage.option.OperandMin = 0
+	}
+
+	if mathImage.option.OperandMax <= 0 {
+		mathImage.option.OperandMax = 9
+	}
+
+	if mathImage.option.OperandMax > mathOperandLimit {
+		mathImage.option.OperandMax = mathOperandLimit
+	}
+
+	if mathImage.option.OperandMin > mathImage.option.OperandMax {
+		mathImage.option.OperandMin = mathImage.option.OperandMax
+	}
+
+	if mathImage.option.OperandCount < 2 {
+		mathImage.option.OperandCount = 2
+	}
+
+	return mathImage
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取标题
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *mathImage) GetTitle() string {
+	return s.textImage.GetTitle()
+}
+
+func (s *mathImage) SetOption(option ImageOption) {
+	s.textImage.SetOption(option)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取图片数据，按Output选择的格式编码
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *mathImage) GetImage() ([]byte, error) {
+	img, err := s.GetRawImage()
+	if err != nil {
+		return nil, err
+	}
+
+	return encodeImage(img, s.textImage.option.Output)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取未编码的图片
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *mathImage) GetRawImage() (image.Image, error) {
+	if err := s.generate(); err != nil {
+		return nil, err
+	}
+
+	s.textImage.count = utf8.RuneCountInString(strings.Join(s.texts, ""))
+
+	graphics, err := s.textImage.drawImage(s.texts)
+	if err != nil {
+		return nil, err
+	}
+
+	return graphics, nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取答案，为计算结果而非绘制的字符
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *mathImage) GetText() []string {
+	if err := s.generate(); err != nil {
+		return nil
+	}
+
+	return []string{strconv.Itoa(s.answer)}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 生成表达式，首次获取答案或图片时执行
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *mathImage) generate() error {
+	if s.isGenerated {
+		return s.generateErr
+	}
+	s.isGenerated = true
+
+	for _, operator := range s.option.Operators {
+		if _, ok := mathOperatorTexts[operator]; !ok {
+			s.generateErr = ErrMathOperator
+			return s.generateErr
+		}
+	}
+
+	for i := 0; i < mathRetryCount; i++ {
+		operands, operators, answer, ok := s.newExpression()
+		if !ok {
+			continue
+		}
+
+		s.answer = answer
+		s.texts = s.getExpressionTexts(operands, operators)
+
+		return nil
+	}
+
+	s.generateErr = ErrMathExpression
+	return s.generateErr
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 随机生成一个表达式并按优先级求值，除法只选择能整除的除数，
+ * 结果为负或中间值溢出时返回false
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *mathImage) newExpression() ([]int, []string, int, bool) {
+	random := s.textImage.answerRandom()
+	min, max := s.option.OperandMin, s.option.OperandMax
+
+	operands := make([]int, 0, s.option.OperandCount)
+	operators := make([]string, 0, s.option.OperandCount-1)
+
+	operand := randIntRange(random, min, max+1)
+	operands = append(operands, operand)
+
+	//sum为已完成的加减项之和，term为当前乘除项
+	sum, sign, term := 0, 1, operand
+
+	for i := 1; i < s.option.OperandCount; i++ {
+		operator := s.option.Operators[random.Intn(len(s.option.Operators))]
+
+		switch operator {
+		case "+", "-":
+			if !addMathTerm(&sum, sign, term) {
+				return nil, nil, 0, false
+			}
+			sign = 1
+			if operator == "-" {
+				sign = -1
+			}
+
+			operand = randIntRange(random, min, max+1)
+			term = operand
+		case "*":
+			operand = randIntRange(random, min, max+1)
+			if operand != 0 && term > mathMaxInt/operand {
+				return nil, nil, 0, false
+			}
+			term *= operand
+		case "/":
+			//0可被任意非零数整除
+			if term == 0 && max > 0 {
+				divisorMin := min
+				if divisorMin < 1 {
+					divisorMin = 1
+				}
+
+				operand = randIntRange(random, divisorMin, max+1)
+				break
+			}
+
+			divisors := getMathDivisors(term, min, max)
+			if len(divisors) == 0 {
+				return nil, nil, 0, false
+			}
+
+			operand = divisors[random.Intn(len(divisors))]
+			term /= operand
+		}
+
+		operands = append(operands, operand)
+		operators = append(operators, operator)
+	}
+
+	answer := sum
+	if !addMathTerm(&answer, sign, term) || answer < 0 {
+		return nil, nil, 0, false
+	}
+
+	return operands, operators, answer, true
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 累加非负的乘除项，溢出时返回false
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func addMathTerm(sum *int, sign, term int) bool {
+	if sign > 0 && *sum > mathMaxInt-term {
+		return false
+	}
+
+	if sign < 0 && *sum < -mathMaxInt+term {
+		return false
+	}
+
+	*sum += sign * term
+
+	return true
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取min,max范围内能整除正数term的除数，成对枚举到平方根
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func getMathDivisors(term, min, max int) []int {
+	divisors := make([]int, 0)
+	if term <= 0 {
+		return divisors
+	}
+
+	for value := 1; value <= max && value <= term/value; value++ {
+		if term%value != 0 {
+			continue
+		}
+
+		if value >= min && value >= 1 {
+			divisors = append(divisors, value)
+		}
+
+		if pair := term / value; pair != value && pair >= min && pair <= max {
+			divisors = append(divisors, pair)
+		}
+	}
+
+	sort.Ints(divisors)
+
+	return divisors
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取表达式绘制字符
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *mathImage) getExpressionTexts(operands []int, operators []string) []string {
+	texts := make([]string, 0)
+
+	for index, operand := range operands {
+		if index > 0 {
+			if s.option.IsChinese {
+				texts = append(texts, mathChineseOperatorTexts[operators[index-1]])
+			} else {
+				texts = append(texts, mathOperatorTexts[operators[index-1]])
+			}
+		}
+
+		if s.option.IsChinese {
+			texts = append(texts, chineseNumber(operand))
+		} else {
+			texts = append(texts, strconv.Itoa(operand))
+		}
+	}
+
+	if s.option.IsChinese {
+		texts = append(texts, "等于", "？")
+	} else {
+		texts = append(texts, "=", "?")
+	}
+
+	return texts
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 非负整数转中文小写数字，如 12 为 十二，105 为 一百零五
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func chineseNumber(number int) string {
+	if number <= 0 {
+		return chineseDigits[0]
+	}
+
+	if number >= 10000 {
+		high, low := number/10000, number%10000
+
+		text := chineseNumber(high) + "万"
+		if low == 0 {
+			return text
+		}
+
+		if low < 1000 {
+			text += chineseDigits[0]
+		}
+
+		return text + getChineseSection(low, true)
+	}
+
+	return getChineseSection(number, false)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 万以内的数字，isInner为true时十位的一不省略，如 一万零一十
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func getChineseSection(number int, isInner bool) string {
+	var builder strings.Builder
+
+	isZero := false
+	for unitIndex := 3; unitIndex >= 0; unitIndex-- {
+		unit := 1
+		for i := 0; i < unitIndex; i++ {
+			unit *= 10
+		}
+
+		digit := number / unit % 10
+		if digit == 0 {
+			isZero = builder.Len() > 0
+			continue
+		}
+
+		if isZero {
+			builder.WriteString(chineseDigits[0])
+			isZero = false
+		}
+
+		//十几省略一
+		if !(unitIndex == 1 && digit == 1 && builder.Len() == 0 && !isInner) {
+			builder.WriteString(chineseDigits[digit])
+		}
+		builder.WriteString(chineseUnits[unitIndex])
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,265 @@
+package gcaptcha
+
+import (
+	"math/big"
+	"strconv"
+	"testing"
+)
+
+func TestChineseNumber(t *testing.T) {
+	tests := []struct {
+		number int
+		want   string
+	}{
+		{-5, "零"},
+		{0, "零"},
+		{1, "一"},
+		{9, "九"},
+		{10, "十"},
+		{11, "十一"},
+		{20, "二十"},
+		{99, "九十九"},
+		{100, "一百"},
+		{101, "一百零一"},
+		{105, "一百零五"},
+		{110, "一百一十"},
+		{1000, "一千"},
+		{1001, "一千零一"},
+		{1010, "一千零一十"},
+		{1100, "一千一百"},
+		{9999, "九千九百九十九"},
+		{10000, "一万"},
+		{10001, "一万零一"},
+		{10010, "一万零一十"},
+		{10100, "一万零一百"},
+		{11000, "一万一千"},
+		{100000, "十万"},
+		{110000, "十一万"},
+		{1000000, "一百万"},
+		{12345678, "一千二百三十四万五千六百七十八"},
+	}
+
+	for _, test := range tests {
+		if got := chineseNumber(test.number); got != test.want {
+			t.Errorf("chineseNumber(%d) = %q, want %q", test.number, got, test.want)
+		}
+	}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 按乘除优先于加减独立求值绘制的表达式，使用big.Int避免溢出，
+ * 除法不能整除时返回错误
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func evalMathTexts(t *testing.T, texts []string) *big.Int {
+	if len(texts) < 5 || texts[len(texts)-2] != "=" || texts[len(texts)-1] != "?" {
+		t.Fatalf("unexpected expression %q", texts)
+	}
+
+	parseOperand := func(text string) *big.Int {
+		value, err := strconv.Atoi(text)
+		if err != nil {
+			t.Fatalf("operand %q in %q: %v", text, texts, err)
+		}
+
+		return big.NewInt(int64(value))
+	}
+
+	sum := new(big.Int)
+	sign := 1
+	term := parseOperand(texts[0])
+
+	for index := 1; index < len(texts)-2; index += 2 {
+		operand := parseOperand(texts[index+1])
+
+		switch texts[index] {
+		case "+", "-":
+			if sign > 0 {
+				sum.Add(sum, term)
+			} else {
+				sum.Sub(sum, term)
+			}
+
+			sign = 1
+			if texts[index] == "-" {
+				sign = -1
+			}
+			term = operand
+		case "×":
+			term = new(big.Int).Mul(term, operand)
+		case "÷":
+			quotient, remainder := new(big.Int).QuoRem(term, operand, new(big.Int))
+			if operand.Sign() == 0 || remainder.Sign() != 0 {
+				t.Fatalf("inexact division %v ÷ %v in %q", term, operand, texts)
+			}
+			term = quotient
+		default:
+			t.Fatalf("unexpected operator %q in %q", texts[index], texts)
+		}
+	}
+
+	if sign > 0 {
+		return sum.Add(sum, term)
+	}
+
+	return sum.Sub(sum, term)
+}
+
+func TestMathImageExpressions(t *testing.T) {
+	options := []MathOption{
+		{},
+		{Operators: []string{"+", "-", "*", "/"}, OperandCount: 4},
+		{Operators: []string{"-"}, OperandCount: 3},
+		{Operators: []string{"/"}, OperandMin: 0, OperandMax: 20, OperandCount: 3},
+		{Operators: []string{"*", "/", "-"}, OperandMin: 2, OperandMax: 100, OperandCount: 5},
+	}
+
+	for optionIndex, option := range options {
+		for seed := int64(1); seed <= 200; seed++ {
+			img := NewMathImage("", option).(*mathImage)
+			img.SetOption(ImageOption{Random: NewRandom(seed)})
+
+			answers := img.GetText()
+			if len(answers) != 1 {
+				t.Fatalf("option %d seed %d: answers %v, err %v", optionIndex, seed, answers, img.generateErr)
+			}
+
+			answer, err := strconv.Atoi(answers[0])
+			if err != nil || answer < 0 {
+				t.Fatalf("option %d seed %d: answer %q is not a non-negative integer", optionIndex, seed, answers[0])
+			}
+
+			if want := evalMathTexts(t, img.texts); want.Cmp(big.NewInt(int64(answer))) != 0 {
+				t.Errorf("option %d seed %d: %q answer %d, want %v", optionIndex, seed, img.texts, answer, want)
+			}
+		}
+	}
+}
+
+func TestMathImagePrecedence(t *testing.T) {
+	img := NewMathImage("", MathOption{}).(*mathImage)
+
+	//2+3×4 为14而非20，10-6÷3 为8
+	tests := []struct {
+		operands  []int
+		operators []string
+		want      string
+	}{
+		{[]int{2, 3, 4}, []string{"+", "*"}, "14"},
+		{[]int{10, 6, 3}, []string{"-", "/"}, "8"},
+		{[]int{2, 3, 4}, []string{"*", "+"}, "10"},
+	}
+
+	for _, test := range tests {
+		texts := img.getExpressionTexts(test.operands, test.operators)
+		if got := evalMathTexts(t, texts).String(); got != test.want {
+			t.Errorf("%q = %s, want %s", texts, got, test.want)
+		}
+	}
+
+	//固定第二个运算符为乘法时，答案按乘法优先计算
+	found := false
+	for seed := int64(1); seed <= 200 && !found; seed++ {
+		img := NewMathImage("", MathOption{Operators: []string{"+", "*"}, OperandMin: 2, OperandCount: 3}).(*mathImage)
+		img.SetOption(ImageOption{Random: NewRandom(seed)})
+		img.GetText()
+
+		if img.texts[1] != "+" || img.texts[3] != "×" {
+			continue
+		}
+		found = true
+
+		a, _ := strconv.Atoi(img.texts[0])
+		b, _ := strconv.Atoi(img.texts[2])
+		c, _ := strconv.Atoi(img.texts[4])
+		if img.answer != a+b*c {
+			t.Errorf("%q: answer %d, want %d", img.texts, img.answer, a+b*c)
+		}
+	}
+
+	if !found {
+		t.Fatal("no a+b×c expression generated")
+	}
+}
+
+func TestMathImageErrors(t *testing.T) {
+	img := NewMathImage("", MathOption{Operators: []string{"+", "%"}})
+	if texts := img.GetText(); texts != nil {
+		t.Errorf("GetText = %v, want nil", texts)
+	}
+
+	if _, err := img.GetImage(); err != ErrMathOperator {
+		t.Errorf("err = %v, want ErrMathOperator", err)
+	}
+
+	//只有减法且最小值大于0时结果总为负
+	img = NewMathImage("", MathOption{Operators: []string{"-"}, OperandMin: 5, OperandMax: 5, OperandCount: 3})
+	if _, err := img.GetImage(); err != ErrMathExpression {
+		t.Errorf("err = %v, want ErrMathExpression", err)
+	}
+}
+
+func TestMathImageLargeOperands(t *testing.T) {
+	options := []MathOption{
+		{Operators: []string{"*"}, OperandMin: mathOperandLimit - 10, OperandMax: mathMaxInt, OperandCount: 3},
+		{Operators: []string{"*", "+"}, OperandMin: 1, OperandMax: mathMaxInt, OperandCount: 6},
+		{Operators: []string{"*", "/"}, OperandMin: 0, OperandMax: mathMaxInt, OperandCount: 4},
+	}
+
+	for optionIndex, option := range options {
+		for seed := int64(1); seed <= 50; seed++ {
+			img := NewMathImage("", option).(*mathImage)
+			img.SetOption(ImageOption{Random: NewRandom(seed)})
+
+			if img.option.OperandMax != mathOperandLimit {
+				t.Fatalf("option %d: operand max %d, want %d", optionIndex, img.option.OperandMax, mathOperandLimit)
+			}
+
+			if err := img.generate(); err != nil {
+				if err != ErrMathExpression {
+					t.Fatalf("option %d seed %d: %v", optionIndex, seed, err)
+				}
+				continue
+			}
+
+			if want := evalMathTexts(t, img.texts); img.answer < 0 || want.Cmp(big.NewInt(int64(img.answer))) != 0 {
+				t.Errorf("option %d seed %d: %q answer %d, want %v", optionIndex, seed, img.texts, img.answer, want)
+			}
+		}
+	}
+
+	//四个百万级操作数的乘积超过int64，只能返回错误
+	img := NewMathImage("", MathOption{Operators: []string{"*"}, OperandMin: mathOperandLimit, OperandCount: 4, OperandMax: mathOperandLimit}).(*mathImage)
+	img.SetOption(ImageOption{Random: NewRandom(1)})
+	if err := img.generate(); err != ErrMathExpression {
+		t.Errorf("overflowing product: err = %v, want ErrMathExpression", err)
+	}
+}
+
+func TestGetMathDivisors(t *testing.T) {
+	tests := []struct {
+		term, min, max int
+		want           []int
+	}{
+		{12, 0, 9, []int{1, 2, 3, 4, 6}},
+		{12, 3, 12, []int{3, 4, 6, 12}},
+		{36, 1, 100, []int{1, 2, 3, 4, 6, 9, 12, 18, 36}},
+		{97, 2, 96, []int{}},
+		{0, 1, 9, []int{}},
+		{1000000007, 1, mathOperandLimit, []int{1}},
+	}
+
+	for _, test := range tests {
+		got := getMathDivisors(test.term, test.min, test.max)
+		if len(got) != len(test.want) {
+			t.Errorf("getMathDivisors(%d, %d, %d) = %v, want %v", test.term, test.min, test.max, got, test.want)
+			continue
+		}
+
+		for index := range got {
+			if got[index] != test.want[index] {
+				t.Errorf("getMathDivisors(%d, %d, %d) = %v, want %v", test.term, test.min, test.max, got, test.want)
+				break
+			}
+		}
+	}
+}
@@ -1,21 +1,21 @@
 package gcaptcha
 
 import (
-	"bytes"
+	"errors"
+	"fmt"
 	"image"
 	"image/color"
 	"image/draw"
-	"image/png"
-	"io/ioutil"
-	"log"
+	"math"
 	"sort"
+	"strings"
 )
 
 import (
 	"github.com/golang/freetype"
-	"github.com/golang/freetype/truetype"
 	"github.com/sanxia/glib"
 	"github.com/sanxia/gmusic"
+	"golang.org/x/image/vector"
 )
 
 /* ================================================================================
@@ -24,7 +24,28 @@ import (
  * email   : 2091938785@qq.com
  * author  : 美丽的地球啊 - mliu
  * ================================================================================ */
+const (
+	MusicClefTreble = "treble"
+	MusicClefBass   = "bass"
+
+	musicStaffTop   float64 = 16.5 //无加线时第五线（最上方）相对线图的y坐标，位于像素中心使直线清晰
+	musicStaffSpace float64 = 16   //相邻两线的间距
+	musicClefWidth  float64 = 50   //谱号占用的宽度
+)
+
+var (
+	ErrMusicName = errors.New("music image: unknown music name")
+
+	musicLetters    = "CDEFGAB"
+	musicLetterKeys = []int{0, 2, 4, 5, 7, 9, 11} //自然音相对C的半音数
+)
+
 type (
+	IMusicStaff interface {
+		SetMusicOption(MusicOption)
+		GetStaffLines() []float64
+	}
+
 	musicImage struct {
 		title   string
 		texts   []string //外部数据源
@@ -36,7 +57,21 @@ type (
 		width   int
 		height  int
 		count   int
+		lines   []int     //最近一次绘制的各音符所在线间索引
+		staves  []float64 //最近一次绘制的五条线的y坐标
 		music   *gmusic.Music
+
+		isGenerated bool
+		generateErr error
+
+		musicOption MusicOption
+	}
+
+	MusicOption struct {
+		StaffWobble float64 //五线谱线条的随机起伏幅度（像素），默认1，小于0时为直线
+		Clef        string  //谱号，treble或bass，默认treble
+		LedgerLines int     //音符可使用的上下加线数量0-3，默认0即只使用五线内外的线间索引0-10，
+		//每条加线使图片上下各需多占用一个线间距
 	}
 )
 
@@ -67,24 +102,74 @@ func NewMusicImage(title string, texts []string, head string, count int) IImage
 	musicImage.colors = append(musicImage.colors, &image.Uniform{color.RGBA{0x00, 0x64, 0x00, 0xff}})
 
 	musicImage.music = gmusic.NewMusic()
+	musicImage.SetMusicOption(MusicOption{})
 
 	return musicImage
 }
 
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取标题
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *musicImage) GetTitle() string {
+	return s.title
+}
+
 func (s *musicImage) SetOption(option ImageOption) {
 	s.option = option
 }
 
 /* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
- * 获取图片数据
+ * 设置五线谱选项
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *musicImage) SetMusicOption(option MusicOption) {
+	if option.StaffWobble == 0 {
+		option.StaffWobble = 1
+	}
+
+	if option.Clef != MusicClefBass {
+		option.Clef = MusicClefTreble
+	}
+
+	if option.LedgerLines < 0 {
+		option.LedgerLines = 0
+	} else if option.LedgerLines > 3 {
+		option.LedgerLines = 3
+	}
+
+	s.musicOption = option
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取最近一次绘制的五条线在图片中的y坐标，自上而下，有起伏时为起伏中心
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *musicImage) GetStaffLines() []float64 {
+	return append([]float64{}, s.staves...)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取图片数据，按Output选择的格式编码
  * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
 func (s *musicImage) GetImage() ([]byte, error) {
-	var imageBuffer bytes.Buffer
+	img, err := s.GetRawImage()
+	if err != nil {
+		return nil, err
+	}
+
+	return encodeImage(img, s.option.Output)
+}
 
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取未编码的图片
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *musicImage) GetRawImage() (image.Image, error) {
 	headerHeight := s.option.HeaderHeight
 	width := s.option.CellWidth
 	height := s.option.CellHeight
-	texts := s.shuffle()
+
+	if err := s.generate(); err != nil {
+		return nil, err
+	}
+	texts := s.GetText()
 
 	s.width = s.count*(width+s.option.Gap) + s.option.Gap + ((s.count - 1) * s.option.Padding)
 	s.height = 1*(height+s.option.Gap) + s.option.Gap + headerHeight + (2 * s.option.Padding)
@@ -106,9 +191,11 @@ func (s *musicImage) GetImage() ([]byte, error) {
 
 	//标题图
 	if len(s.title) > 0 {
-		if titleImage, err := s.getTitleImage(); err == nil {
-			draw.Draw(graphics, titleImage.Bounds().Add(offsetPoint), titleImage, image.ZP, draw.Over)
+		titleImage, err := s.getTitleImage()
+		if err != nil {
+			return nil, err
 		}
+		draw.Draw(graphics, titleImage.Bounds().Add(offsetPoint), titleImage, image.ZP, draw.Over)
 	}
 
 	offsetPoint = image.Point{s.option.Padding, offsetPoint.Y}
@@ -117,14 +204,23 @@ func (s *musicImage) GetImage() ([]byte, error) {
 	}
 
 	//线条图
-	musicImage, offsets, _ := s.getMusicLineImage()
+	musicImage, err := s.getMusicLineImage()
+	if err != nil {
+		return nil, err
+	}
 	draw.Draw(graphics, musicImage.Bounds().Add(offsetPoint), musicImage, image.ZP, draw.Over)
 
-	//音名图
-	if circleImage, err := s.getMusicNameImage(texts, offsets); err == nil {
-		offsetPoint.X = offsetPoint.X + glib.RandIntRange(30, 50)
-		draw.Draw(graphics, circleImage.Bounds().Add(offsetPoint), circleImage, image.ZP, draw.Over)
+	s.staves = make([]float64, 0, 5)
+	for index := 9; index > 0; index -= 2 {
+		s.staves = append(s.staves, s.getStaffY(index)+float64(offsetPoint.Y))
+	}
+
+	//音符图
+	noteImage, err := s.getMusicNameImage(texts)
+	if err != nil {
+		return nil, err
 	}
+	draw.Draw(graphics, noteImage.Bounds().Add(offsetPoint), noteImage, image.ZP, draw.Over)
 
 	//谱号图
 	if len(s.head) > 0 {
@@ -132,15 +228,17 @@ func (s *musicImage) GetImage() ([]byte, error) {
 		draw.Draw(graphics, clefHightImage.Bounds().Add(image.Point{10, 60}), clefHightImage, image.ZP, draw.Over)
 	} else {
 		if clefImage, err := s.getMusicClefImage(); err == nil {
-			draw.Draw(graphics, clefImage.Bounds().Add(image.Point{10, 40}), clefImage, image.ZP, draw.Over)
+			draw.Draw(graphics, clefImage.Bounds().Add(offsetPoint), clefImage, image.ZP, draw.Over)
 		}
 	}
 
-	if err := png.Encode(&imageBuffer, graphics); err != nil {
-		return nil, err
-	}
+	//噪声
+	drawNoises(graphics, s.option.Noises, nil, s.random())
+
+	//滤镜
+	graphics = applyFilters(graphics, s.option.Filters, s.random())
 
-	return imageBuffer.Bytes(), nil
+	return graphics, nil
 }
 
 /* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
@@ -150,7 +248,14 @@ func (s *musicImage) getTitleImage() (image.Image, error) {
 	graphics := image.NewRGBA(image.Rect(0, 0, s.width, s.height))
 	draw.Draw(graphics, graphics.Bounds(), image.Transparent, image.ZP, draw.Src)
 
-	font, _ := s.getFont(s.option.FontPath)
+	font, err := GetFont(s.option.FontPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkFontGlyphs(font, s.title); err != nil {
+		return nil, err
+	}
 
 	ctx := freetype.NewContext()
 	ctx.SetDPI(72)
@@ -175,123 +280,112 @@ func (s *musicImage) getTitleImage() (image.Image, error) {
 }
 
 /* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
- * 获取线图
+ * 获取线图，五条抗锯齿矢量线铺满画布宽度
  * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
-func (s *musicImage) getMusicLineImage() (image.Image, []int, error) {
+func (s *musicImage) getMusicLineImage() (image.Image, error) {
 	dstImg := image.NewRGBA(image.Rect(0, 0, s.width, s.height))
 	draw.Draw(dstImg, dstImg.Bounds(), image.Transparent, image.ZP, draw.Src)
 
-	font, _ := s.getFont(s.option.FontPath)
-	rowOffsets := make([]int, 0)
-
-	ctx := freetype.NewContext()
-	ctx.SetDPI(72)
-	ctx.SetFontSize(6)
-	ctx.SetFont(font)
-	ctx.SetClip(dstImg.Bounds())
-	ctx.SetDst(dstImg)
-	ctx.SetSrc(image.Black)
-
-	rowOffset := freetype.Pt(0, 0)
-	for i := 0; i < 5; i++ {
-		rowOffset.Y = ctx.PointToFixed(float64((i+1)*16) + float64(glib.RandIntRange(1, 2)))
-
-		rowOffsets = append(rowOffsets, rowOffset.Y.Floor()-8)
-		rowOffsets = append(rowOffsets, rowOffset.Y.Floor())
-
-		if i == 4 {
-			rowOffsets = append(rowOffsets, rowOffset.Y.Floor()+8)
-		}
-
-		lineOffset := freetype.Pt(0, 0)
-		for j := 0; j < 94; j++ {
-			if j > 0 {
-				lineOffset.X += ctx.PointToFixed(float64(2.5))
-			}
-			lineOffset.Y = rowOffset.Y + ctx.PointToFixed(float64(glib.RandIntRange(0, 2)))
-			if _, err := ctx.DrawString("-", lineOffset); err != nil {
-				return nil, nil, err
+	width := float64(s.width - 2*s.option.Padding)
+	wobble := s.musicOption.StaffWobble
+
+	//自下而上为第一线至第五线
+	rasterizer := newRasterizer(dstImg)
+	for index := 1; index < 10; index += 2 {
+		lineY := s.getStaffY(index)
+
+		points := []shapePoint{{0, lineY}, {width, lineY}}
+		if wobble > 0 {
+			//低频正弦起伏，线的y坐标为起伏中心
+			amplitude := randFloatRange(s.random(), wobble/2, wobble)
+			period := randFloatRange(s.random(), 80, 160)
+			phase := randFloatRange(s.random(), 0, 2*math.Pi)
+
+			points = make([]shapePoint, 0)
+			for x := 0.0; x < width+4; x += 4 {
+				x = math.Min(x, width)
+				points = append(points, shapePoint{x, lineY + amplitude*math.Sin(2*math.Pi*x/period+phase)})
 			}
 		}
-	}
 
-	sort.Sort(sort.Reverse(sort.IntSlice(rowOffsets)))
+		addPolyline(rasterizer, points, 1.2)
+	}
+	drawRasterizer(dstImg, rasterizer, image.Black)
 
-	return dstImg, rowOffsets, nil
+	return dstImg, nil
 }
 
 /* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
- * 获取音名图
+ * 获取音符图：椭圆符头、按位置朝上或朝下的符干、五线外的加线和符头左侧的升降号
  * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
-func (s *musicImage) getMusicNameImage(texts []string, offsets []int) (image.Image, error) {
+func (s *musicImage) getMusicNameImage(texts []string) (image.Image, error) {
 	dstImg := image.NewRGBA(image.Rect(0, 0, s.width, s.height))
 	draw.Draw(dstImg, dstImg.Bounds(), image.Transparent, image.ZP, draw.Src)
 
-	offsetPoint := image.Point{}
+	s.lines = make([]int, 0, len(texts))
 
-	log.Printf("texts: %#v", texts)
+	//谱号之后的宽度均分给各音符
+	step := (float64(s.width-2*s.option.Padding) - musicClefWidth) / float64(len(texts))
+	radiusX, _ := getMusicHeadRadius(musicStaffSpace)
 
-	for _, text := range texts {
+	for index, text := range texts {
 		musicName := s.music.GetMusicNameByCode(text)
-
-		musicLineIndexs := make([]int, 0)
-		for _, musicLine := range s.music.GetMusicLinesByMusicName(musicName.Name) {
-			musicLineIndexs = append(musicLineIndexs, musicLine.Index)
+		if musicName == nil {
+			return nil, fmt.Errorf("%w: %q", ErrMusicName, text)
 		}
 
+		musicLineIndexs := s.getMusicPositions(musicName)
 		musicLineIndex := musicLineIndexs[0]
 		if len(musicLineIndexs) > 1 {
-			currentLocationIndex := glib.RandIntRange(0, len(musicLineIndexs))
+			currentLocationIndex := randIntRange(s.random(), 0, len(musicLineIndexs))
 			musicLineIndex = musicLineIndexs[currentLocationIndex]
 		}
+		s.lines = append(s.lines, musicLineIndex)
 
-		offsetX := 15 + glib.RandIntRange(8, 15)
-		offsetY := offsets[musicLineIndex] - 5
-
-		if musicName.IsBlack() {
-			offsetY = offsets[musicLineIndex] - 4
+		//升降号占用符头左侧的空间
+		center := shapePoint{
+			musicClefWidth + step*(float64(index)+0.5) + randFloatRange(s.random(), -step/8, step/8) + radiusX/2,
+			s.getStaffY(musicLineIndex),
 		}
 
-		offsetPoint.X += offsetX
-		offsetPoint.Y = offsetY
-
-		log.Printf("musicName:%s, musicNameIndex:%d, musicLine index:%d, musicLine indexs:%#v", musicName.Name, musicName.Index, musicLineIndex, musicLineIndexs)
-
-		if musicName.IsBlack() {
-			//升降号
-			font, _ := s.getFont(s.option.FontPath)
-			ctx := freetype.NewContext()
-			ctx.SetDPI(72)
-			ctx.SetFontSize(10)
-			ctx.SetFont(font)
-			ctx.SetClip(dstImg.Bounds())
-			ctx.SetDst(dstImg)
-			ctx.SetSrc(image.Black)
-
-			pt := freetype.Pt(offsetPoint.X-7, offsetPoint.Y+5)
-			if _, err := ctx.DrawString(string(text[0]), pt); err != nil {
-				return nil, err
-			}
+		//加线
+		ledgerRasterizer := newRasterizer(dstImg)
+		for ledgerIndex := -1; ledgerIndex >= musicLineIndex; ledgerIndex -= 2 {
+			s.addLedgerLine(ledgerRasterizer, center[0], ledgerIndex)
+		}
+		for ledgerIndex := 11; ledgerIndex <= musicLineIndex; ledgerIndex += 2 {
+			s.addLedgerLine(ledgerRasterizer, center[0], ledgerIndex)
+		}
+		drawRasterizer(dstImg, ledgerRasterizer, image.Black)
+
+		//符头、符干和升降号，第三线及以上符干朝下
+		rasterizer := newRasterizer(dstImg)
+		addMusicNote(rasterizer, center, musicStaffSpace, musicLineIndex < 5)
+
+		accidentalCenter := shapePoint{center[0] - radiusX - musicStaffSpace*0.6, center[1]}
+		switch musicName.Name[0] {
+		case '#':
+			addMusicSharp(rasterizer, accidentalCenter, musicStaffSpace)
+		case 'b':
+			addMusicFlat(rasterizer, accidentalCenter, musicStaffSpace)
 		}
 
-		//音名
-		/*
-			srcImg := &image.Uniform{color.RGBA{120, 126, 60, 255}}
-			if len(text) > 1 {
-				srcImg = &image.Uniform{color.RGBA{200, 200, 200, 255}}
-			}
-		*/
-
-		srcImg := s.colors[glib.RandIntRange(0, 5)]
-
-		dstRect := image.Rect(0, 0, 5, 8).Add(offsetPoint)
-		draw.Draw(dstImg, dstRect, srcImg, image.ZP, draw.Src)
-
+		drawRasterizer(dstImg, rasterizer, s.colors[randIntRange(s.random(), 0, len(s.colors))])
 	}
 
 	return dstImg, nil
 }
 
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 添加以x为中心的短加线
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *musicImage) addLedgerLine(rasterizer *vector.Rasterizer, x float64, musicLineIndex int) {
+	radiusX, _ := getMusicHeadRadius(musicStaffSpace)
+	y := s.getStaffY(musicLineIndex)
+
+	addPolyline(rasterizer, []shapePoint{{x - radiusX*1.6, y}, {x + radiusX*1.6, y}}, 1.2)
+}
+
 /* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
  * 获取谱号图
  * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
@@ -299,33 +393,97 @@ func (s *musicImage) getMusicClefImage() (image.Image, error) {
 	dstImg := image.NewRGBA(image.Rect(0, 0, s.width, s.height))
 	draw.Draw(dstImg, dstImg.Bounds(), image.Transparent, image.ZP, draw.Src)
 
-	srcImg := &image.Uniform{color.RGBA{90, 50, 160, 255}}
-
-	dstRect := image.Rect(0, 0, 30, 55)
-	draw.Draw(dstImg, dstRect, srcImg, image.ZP, draw.Src)
+	rasterizer := newRasterizer(dstImg)
+	if s.musicOption.Clef == MusicClefBass {
+		addMusicBassClef(rasterizer, shapePoint{12, s.getStaffY(7)}, musicStaffSpace)
+	} else {
+		addMusicTrebleClef(rasterizer, shapePoint{22, s.getStaffY(3)}, musicStaffSpace)
+	}
+	drawRasterizer(dstImg, rasterizer, image.Black)
 
 	return dstImg, nil
 }
 
-func (s *musicImage) shuffle() []string {
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取线间索引相对线图的y坐标，1为第一线（最下方），9为第五线，
+ * 小于0或大于10为加线和加间，每条加线使五线谱下移一个线间距
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *musicImage) getStaffY(musicLineIndex int) float64 {
+	bottom := musicStaffTop + float64(4+s.musicOption.LedgerLines)*musicStaffSpace
+
+	return bottom - float64(musicLineIndex-1)*musicStaffSpace/2
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取音名在当前谱号下可用的线间索引，由低到高
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *musicImage) getMusicPositions(musicName *gmusic.MusicName) []int {
+	letter := strings.IndexByte(musicLetters, musicName.Name[len(musicName.Name)-1])
+	ledgerLines := s.musicOption.LedgerLines
+
+	positions := make([]int, 0)
+	for position := -2 * ledgerLines; position <= 10+2*ledgerLines; position++ {
+		if s.getDiatonic(position)%7 == letter {
+			positions = append(positions, position)
+		}
+	}
+
+	return positions
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取线间索引对应的自然音序号（八度 * 7 + 音名），高音谱号索引0为D4，低音谱号为F2
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *musicImage) getDiatonic(musicLineIndex int) int {
+	if s.musicOption.Clef == MusicClefBass {
+		return 2*7 + 3 + musicLineIndex
+	}
+
+	return 4*7 + 1 + musicLineIndex
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 随机选择音名，首次获取答案或图片时执行，之后的图片都使用相同的音名
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *musicImage) generate() error {
+	if s.isGenerated {
+		return s.generateErr
+	}
+	s.isGenerated = true
+
+	if s.count <= 0 || s.count > len(s.texts) {
+		s.generateErr = fmt.Errorf("music image: count %d out of range 1-%d", s.count, len(s.texts))
+		return s.generateErr
+	}
+
+	s.shuffle()
+
+	return nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 随机音名
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *musicImage) shuffle() {
 	//随机打散texts到cellMap
 	for index, text := range s.texts {
 		s.itemMap[index] = text
 	}
 
-	index := glib.RandIntRange(0, s.count)
+	//首个位置同样在全部文字中选取，避免偏向前count个
+	index := randIntRange(s.answerRandom(), 0, len(s.itemMap))
 	s.cellMap[index] = s.itemMap[index]
 
 	count := s.count
 
 	//第一个已提前写入，所以是大于1
 	for count > 1 {
-		index = glib.RandIntRange(0, len(s.itemMap))
+		index = randIntRange(s.answerRandom(), 0, len(s.itemMap))
 		for {
 			if _, ok := s.cellMap[index]; !ok {
 				break
 			} else {
-				index = glib.RandIntRange(0, len(s.itemMap))
+				index = randIntRange(s.answerRandom(), 0, len(s.itemMap))
 			}
 		}
 
@@ -333,47 +491,48 @@ func (s *musicImage) shuffle() []string {
 
 		count--
 	}
-
-	return s.GetText()
 }
 
 /* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
  * 获取文字宽度
  * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
-func (s *musicImage) getTextWidth(fontSize int) int {
-	font, _ := s.getFont(s.option.FontPath)
+func (s *musicImage) getTextWidth(fontSize int) (int, error) {
+	font, err := GetFont(s.option.FontPath)
+	if err != nil {
+		return 0, err
+	}
+
 	ctx := freetype.NewContext()
 	ctx.SetDPI(72)
 	ctx.SetFontSize(float64(fontSize))
 	ctx.SetFont(font)
 	space := float64(fontSize)
 
-	return int(ctx.PointToFixed(space))
+	return int(ctx.PointToFixed(space)), nil
 }
 
 /* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
- * 获取字体
+ * 获取随机数源
  * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
-func (s *musicImage) getFont(fontPath string) (*truetype.Font, error) {
-	absolutePath := glib.GetAbsolutePath(fontPath)
-
-	fontBytes, err := ioutil.ReadFile(absolutePath)
-	if err != nil {
-		return nil, err
-	}
-
-	font, err := freetype.ParseFont(fontBytes)
-	if err != nil {
-		return nil, err
-	}
+func (s *musicImage) random() IRandom {
+	return getRandom(s.option.Random)
+}
 
-	return font, nil
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取答案选择随机数源
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *musicImage) answerRandom() IRandom {
+	return getAnswerRandom(s.option.Random, s.option.IsSecure)
 }
 
 /* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
  * 获取文字
  * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
 func (s *musicImage) GetText() []string {
+	if err := s.generate(); err != nil {
+		return nil
+	}
+
 	//维持字典索引有序
 	keys := make([]int, 0)
 	for keyIndex := range s.cellMap {
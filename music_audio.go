@@ -0,0 +1,221 @@
+package gcaptcha
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+/* ================================================================================
+ * 五线谱音频
+ * qq group: 582452342
+ * email   : 2091938785@qq.com
+ * author  : 美丽的地球啊 - mliu
+ * ================================================================================ */
+const (
+	MusicTimbreSine   = "sine"
+	MusicTimbreSquare = "square"
+)
+
+type (
+	IMusicAudio interface {
+		GetAudio(option MusicAudioOption) ([]byte, error)
+	}
+
+	MusicAudioOption struct {
+		SampleRate int           //采样率，默认22050
+		Timbre     string        //音色，sine为带少量泛音的正弦波，square为奇次谐波叠加的方波，默认sine
+		Duration   time.Duration //每个音的时长，默认600ms
+		Gap        time.Duration //音之间的停顿，默认150ms
+		Attack     time.Duration //ADSR包络：起音，默认10ms
+		Decay      time.Duration //衰减，默认80ms
+		Sustain    float64       //持续电平0-1，默认0.6，负数表示电平0
+		Release    time.Duration //释音，默认120ms，包含在Duration内
+	}
+
+	musicNote struct {
+		name string //音名
+		line int    //线间索引
+		key  int    //MIDI音高
+	}
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取WAV数据，按GetText()的顺序依次演奏图片中的音符，音高与最近一次绘制的
+ * 线间位置一致，未绘制时取最低位置
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *musicImage) GetAudio(option MusicAudioOption) ([]byte, error) {
+	option = s.getAudioOption(option)
+
+	notes, err := s.getMusicNotes()
+	if err != nil {
+		return nil, err
+	}
+
+	sampleRate := option.SampleRate
+	gap := make([]float64, int(option.Gap.Seconds()*float64(sampleRate)))
+
+	samples := append([]float64{}, gap...)
+	for _, note := range notes {
+		samples = append(samples, synthNote(musicNoteFrequency(note.key), option)...)
+		samples = append(samples, gap...)
+	}
+
+	normalizeSamples(samples)
+
+	return writeWav(samples, sampleRate)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 填充音频选项默认值
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *musicImage) getAudioOption(option MusicAudioOption) MusicAudioOption {
+	if option.SampleRate <= 0 {
+		option.SampleRate = 22050
+	}
+
+	if option.Timbre == "" {
+		option.Timbre = MusicTimbreSine
+	}
+
+	if option.Duration <= 0 {
+		option.Duration = 600 * time.Millisecond
+	}
+
+	if option.Gap <= 0 {
+		option.Gap = 150 * time.Millisecond
+	}
+
+	if option.Attack <= 0 {
+		option.Attack = 10 * time.Millisecond
+	}
+
+	if option.Decay <= 0 {
+		option.Decay = 80 * time.Millisecond
+	}
+
+	//0为未设置，持续电平为0需传入负数
+	if option.Sustain == 0 {
+		option.Sustain = 0.6
+	} else if option.Sustain < 0 {
+		option.Sustain = 0
+	} else if option.Sustain > 1 {
+		option.Sustain = 1
+	}
+
+	if option.Release <= 0 {
+		option.Release = 120 * time.Millisecond
+	}
+
+	return option
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取音符序列
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *musicImage) getMusicNotes() ([]musicNote, error) {
+	if err := s.generate(); err != nil {
+		return nil, err
+	}
+
+	texts := s.GetText()
+
+	notes := make([]musicNote, 0, len(texts))
+	for index, text := range texts {
+		musicName := s.music.GetMusicNameByCode(text)
+		if musicName == nil {
+			return nil, fmt.Errorf("%w: %q", ErrMusicName, text)
+		}
+
+		line := s.getMusicPositions(musicName)[0]
+		if len(s.lines) == len(texts) {
+			line = s.lines[index]
+		}
+
+		//线间决定自然音，首字符决定升降
+		diatonic := s.getDiatonic(line)
+		key := (diatonic/7+1)*12 + musicLetterKeys[diatonic%7]
+		switch musicName.Name[0] {
+		case '#':
+			key++
+		case 'b':
+			key--
+		}
+
+		notes = append(notes, musicNote{
+			name: musicName.Name,
+			line: line,
+			key:  key,
+		})
+	}
+
+	return notes, nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * MIDI音高转频率，A4(69) = 440Hz
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func musicNoteFrequency(key int) float64 {
+	return 440 * math.Pow(2, float64(key-69)/12)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 加法合成单个音并施加ADSR包络，高于奈奎斯特频率的谐波被舍弃
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func synthNote(frequency float64, option MusicAudioOption) []float64 {
+	sampleRate := float64(option.SampleRate)
+
+	//谐波次数和振幅
+	partials := [][2]float64{{1, 0.7}, {2, 0.2}, {3, 0.1}}
+	if option.Timbre == MusicTimbreSquare {
+		partials = make([][2]float64, 0)
+		for harmonic := 1; harmonic <= 15; harmonic += 2 {
+			partials = append(partials, [2]float64{float64(harmonic), 0.6 / float64(harmonic)})
+		}
+	}
+
+	samples := make([]float64, int(option.Duration.Seconds()*sampleRate))
+	for index := range samples {
+		t := float64(index) / sampleRate
+
+		var value float64
+		for _, partial := range partials {
+			partialFrequency := frequency * partial[0]
+			if partialFrequency >= sampleRate/2 {
+				continue
+			}
+			value += partial[1] * math.Sin(2*math.Pi*partialFrequency*t)
+		}
+
+		samples[index] = value * adsrEnvelope(t, option)
+	}
+
+	return samples
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * ADSR包络在t秒处的电平，释音在时长末尾从当前电平线性降到0
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func adsrEnvelope(t float64, option MusicAudioOption) float64 {
+	attack := option.Attack.Seconds()
+	decay := option.Decay.Seconds()
+	release := math.Min(option.Release.Seconds(), option.Duration.Seconds())
+	releaseStart := option.Duration.Seconds() - release
+
+	level := func(t float64) float64 {
+		switch {
+		case t < attack:
+			return t / attack
+		case t < attack+decay:
+			return 1 - (1-option.Sustain)*(t-attack)/decay
+		default:
+			return option.Sustain
+		}
+	}
+
+	if t < releaseStart {
+		return level(t)
+	}
+
+	return level(releaseStart) * math.Max(0, 1-(t-releaseStart)/release)
+}
@@ -0,0 +1,131 @@
+package gcaptcha
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestMusicAudioOption(t *testing.T) {
+	img := NewMusicImage("", []string{"C", "D", "E", "F"}, "", 4).(*musicImage)
+
+	tests := []struct {
+		sustain float64
+		want    float64
+	}{
+		{0, 0.6},
+		{-1, 0},
+		{0.3, 0.3},
+		{1, 1},
+		{2, 1},
+	}
+
+	for _, test := range tests {
+		if got := img.getAudioOption(MusicAudioOption{Sustain: test.sustain}).Sustain; got != test.want {
+			t.Errorf("sustain %v: got %v, want %v", test.sustain, got, test.want)
+		}
+	}
+}
+
+func TestMusicAudioWav(t *testing.T) {
+	img := NewMusicImage("", []string{"C", "D", "E", "F", "G", "A"}, "", 4).(*musicImage)
+	img.SetOption(ImageOption{Random: NewRandom(1)})
+
+	option := MusicAudioOption{
+		SampleRate: 8000,
+		Duration:   250 * time.Millisecond,
+		Gap:        50 * time.Millisecond,
+	}
+
+	data, err := img.GetAudio(option)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var header struct {
+		Riff          [4]byte
+		Size          uint32
+		Wave          [4]byte
+		Fmt           [4]byte
+		FmtSize       uint32
+		AudioFormat   uint16
+		Channels      uint16
+		SampleRate    uint32
+		ByteRate      uint32
+		BlockAlign    uint16
+		BitsPerSample uint16
+		Data          [4]byte
+		DataSize      uint32
+	}
+	if err := binary.Read(bytes.NewReader(data), binary.LittleEndian, &header); err != nil {
+		t.Fatal(err)
+	}
+
+	if string(header.Riff[:]) != "RIFF" || string(header.Wave[:]) != "WAVE" ||
+		string(header.Fmt[:]) != "fmt " || string(header.Data[:]) != "data" {
+		t.Fatalf("unexpected chunk ids: %+v", header)
+	}
+
+	if header.AudioFormat != 1 || header.Channels != 1 || header.SampleRate != 8000 ||
+		header.ByteRate != 16000 || header.BlockAlign != 2 || header.BitsPerSample != 16 {
+		t.Errorf("unexpected format: %+v", header)
+	}
+
+	if int(header.Size) != len(data)-8 || int(header.DataSize) != len(data)-44 {
+		t.Errorf("sizes %d and %d for %d bytes", header.Size, header.DataSize, len(data))
+	}
+
+	//首尾和音之间各有一段停顿
+	noteSamples := 8000 * 250 / 1000
+	gapSamples := 8000 * 50 / 1000
+	if want := gapSamples + 4*(noteSamples+gapSamples); int(header.DataSize)/2 != want {
+		t.Errorf("%d samples, want %d", header.DataSize/2, want)
+	}
+}
+
+func TestMusicAudioEnvelope(t *testing.T) {
+	img := NewMusicImage("", nil, "", 0).(*musicImage)
+	option := img.getAudioOption(MusicAudioOption{
+		Duration: time.Second,
+		Attack:   100 * time.Millisecond,
+		Decay:    200 * time.Millisecond,
+		Sustain:  0.5,
+		Release:  300 * time.Millisecond,
+	})
+
+	tests := []struct {
+		t    float64
+		want float64
+	}{
+		{0, 0},
+		{0.05, 0.5},
+		{0.1, 1},
+		{0.2, 0.75},
+		{0.3, 0.5},
+		{0.7, 0.5},
+		{0.85, 0.25},
+		{1, 0},
+	}
+
+	for _, test := range tests {
+		if got := adsrEnvelope(test.t, option); math.Abs(got-test.want) > 1e-9 {
+			t.Errorf("envelope at %vs = %v, want %v", test.t, got, test.want)
+		}
+	}
+
+	//持续电平为0时衰减后静音
+	option = img.getAudioOption(MusicAudioOption{Sustain: -1})
+	if got := adsrEnvelope(option.Attack.Seconds()+option.Decay.Seconds(), option); got != 0 {
+		t.Errorf("zero sustain: level %v after decay, want 0", got)
+	}
+
+	samples := synthNote(440, option)
+	middle := len(samples) / 2
+	for index := middle - 10; index < middle+10; index++ {
+		if samples[index] != 0 {
+			t.Fatalf("zero sustain: sample %d is %v, want 0", index, samples[index])
+		}
+	}
+}
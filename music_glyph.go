@@ -0,0 +1,125 @@
+package gcaptcha
+
+import (
+	"golang.org/x/image/vector"
+)
+
+/* ================================================================================
+ * 五线谱矢量符号，尺寸以线间距space为单位，y轴向下
+ * qq group: 582452342
+ * email   : 2091938785@qq.com
+ * author  : 美丽的地球啊 - mliu
+ * ================================================================================ */
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取符头半径，高度略小于线间距
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func getMusicHeadRadius(space float64) (float64, float64) {
+	return space * 0.6, space * 0.42
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 添加实心符头和符干，符干向上时位于符头右侧，向下时位于左侧
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func addMusicNote(rasterizer *vector.Rasterizer, center shapePoint, space float64, isStemUp bool) {
+	radiusX, radiusY := getMusicHeadRadius(space)
+	addPolygon(rasterizer, ellipsePoints(center, radiusX, radiusY, -0.35, false))
+
+	//符干与旋转后的符头边缘相接
+	stemX := center[0] + radiusX*0.9
+	stemY, stemEnd := center[1]-space*0.15, center[1]-space*3.5
+	if !isStemUp {
+		stemX = center[0] - radiusX*0.9
+		stemY, stemEnd = center[1]+space*0.15, center[1]+space*3.5
+	}
+
+	addPolyline(rasterizer, []shapePoint{{stemX, stemY}, {stemX, stemEnd}}, space*0.09)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 添加升号，center为所在线间的中心
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func addMusicSharp(rasterizer *vector.Rasterizer, center shapePoint, space float64) {
+	x, y := center[0], center[1]
+
+	//两条竖线，右侧略高
+	addPolyline(rasterizer, []shapePoint{{x - space*0.17, y - space*1.2}, {x - space*0.17, y + space*1.35}}, space*0.08)
+	addPolyline(rasterizer, []shapePoint{{x + space*0.17, y - space*1.35}, {x + space*0.17, y + space*1.2}}, space*0.08)
+
+	//两条向右上倾斜的粗横线
+	for _, offsetY := range []float64{-space * 0.38, space * 0.38} {
+		addPolyline(rasterizer, []shapePoint{
+			{x - space*0.42, y + offsetY + space*0.12},
+			{x + space*0.42, y + offsetY - space*0.12},
+		}, space*0.2)
+	}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 添加降号，符腹位于center所在线间
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func addMusicFlat(rasterizer *vector.Rasterizer, center shapePoint, space float64) {
+	x, y := center[0]-space*0.2, center[1]
+
+	addPolyline(rasterizer, []shapePoint{{x, y - space*1.75}, {x, y + space*0.5}}, space*0.09)
+	addPolyline(rasterizer, cubicPoints(
+		shapePoint{x, y - space*0.1},
+		shapePoint{x + space*0.45, y - space*0.6},
+		shapePoint{x + space*0.75, y},
+		shapePoint{x, y + space*0.5},
+		16,
+	), space*0.14)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 添加高音谱号，origin为旋涡中心，位于第二线（G）上
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func addMusicTrebleClef(rasterizer *vector.Rasterizer, origin shapePoint, space float64) {
+	point := func(x, y float64) shapePoint {
+		return shapePoint{origin[0] + x*space, origin[1] + y*space}
+	}
+
+	//旋涡 -> 左侧上行 -> 顶部圈 -> 贯穿的下行主干 -> 尾钩
+	segments := [][4]shapePoint{
+		{point(0.1, 0.25), point(-0.2, 0.45), point(-0.55, 0.4), point(-0.55, 0.05)},
+		{point(-0.55, 0.05), point(-0.55, -0.4), point(-0.3, -0.75), point(0.05, -0.75)},
+		{point(0.05, -0.75), point(0.45, -0.75), point(0.75, -0.3), point(0.7, 0.15)},
+		{point(0.7, 0.15), point(0.65, 0.65), point(0.3, 0.95), point(-0.05, 0.95)},
+		{point(-0.05, 0.95), point(-0.55, 0.95), point(-0.9, 0.45), point(-0.85, -0.05)},
+		{point(-0.85, -0.05), point(-0.8, -0.9), point(0.35, -1.4), point(0.3, -2.3)},
+		{point(0.3, -2.3), point(0.25, -2.9), point(0.05, -3.3), point(-0.1, -3.1)},
+		{point(-0.1, -3.1), point(-0.35, -2.8), point(-0.25, -2.4), point(-0.2, -2.0)},
+		{point(-0.2, -2.0), point(-0.1, -0.7), point(0.1, 1.0), point(0.15, 2.1)},
+		{point(0.15, 2.1), point(0.2, 2.6), point(-0.3, 2.65), point(-0.4, 2.3)},
+	}
+
+	points := make([]shapePoint, 0)
+	for _, segment := range segments {
+		curve := cubicPoints(segment[0], segment[1], segment[2], segment[3], 16)
+		if len(points) > 0 {
+			curve = curve[1:]
+		}
+		points = append(points, curve...)
+	}
+
+	addPolyline(rasterizer, points, space*0.13)
+	addPolygon(rasterizer, circlePoints(point(-0.3, 2.2), space*0.2, false))
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 添加低音谱号，origin为起笔圆点，位于第四线（F）上，两个圆点夹住第四线
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func addMusicBassClef(rasterizer *vector.Rasterizer, origin shapePoint, space float64) {
+	point := func(x, y float64) shapePoint {
+		return shapePoint{origin[0] + x*space, origin[1] + y*space}
+	}
+
+	addPolygon(rasterizer, circlePoints(point(0, 0), space*0.28, false))
+
+	points := cubicPoints(point(-0.1, -0.1), point(0.1, -1.0), point(1.25, -0.95), point(1.25, 0.15), 20)
+	points = append(points, cubicPoints(point(1.25, 0.15), point(1.25, 1.1), point(0.6, 1.8), point(-0.15, 2.2), 20)[1:]...)
+	addPolyline(rasterizer, points, space*0.17)
+
+	addPolygon(rasterizer, circlePoints(point(1.65, -0.5), space*0.13, false))
+	addPolygon(rasterizer, circlePoints(point(1.65, 0.5), space*0.13, false))
+}
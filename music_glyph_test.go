@@ -0,0 +1,135 @@
+package gcaptcha
+
+import (
+	"image"
+	"image/draw"
+	"testing"
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 白底黑色墨迹的外接矩形，覆盖过半的像素计入
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func getInkBounds(img *image.RGBA) image.Rectangle {
+	bounds := image.Rectangle{}
+	for y := img.Rect.Min.Y; y < img.Rect.Max.Y; y++ {
+		for x := img.Rect.Min.X; x < img.Rect.Max.X; x++ {
+			if img.RGBAAt(x, y).R < 128 {
+				bounds = bounds.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+
+	return bounds
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 在白底上绘制单个符号，线图坐标与默认五线谱一致
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func drawTestMusicGlyph(add func(*musicImage, *image.RGBA)) (*musicImage, *image.RGBA) {
+	img := NewMusicImage("", nil, "", 0).(*musicImage)
+	img.width, img.height = 200, 120
+
+	graphics := newTestNoiseImage(img.width, img.height)
+	add(img, graphics)
+
+	return img, graphics
+}
+
+func TestMusicAccidentals(t *testing.T) {
+	//升降号位于第二线与第三线之间的线间
+	const lineIndex = 4
+
+	img, graphics := drawTestMusicGlyph(func(s *musicImage, dst *image.RGBA) {
+		rasterizer := newRasterizer(dst)
+		addMusicSharp(rasterizer, shapePoint{50, s.getStaffY(lineIndex)}, musicStaffSpace)
+		drawRasterizer(dst, rasterizer, image.Black)
+	})
+
+	//升号居中于线间，竖线上下各超出约一个线间距，宽度不超过一个线间距
+	y := img.getStaffY(lineIndex)
+	bounds := getInkBounds(graphics)
+	if float64(bounds.Min.Y) > img.getStaffY(lineIndex+2) || float64(bounds.Max.Y) < img.getStaffY(lineIndex-2) {
+		t.Errorf("sharp bounds %v do not reach the neighbouring lines %.1f and %.1f", bounds, img.getStaffY(lineIndex+2), img.getStaffY(lineIndex-2))
+	}
+
+	if float64(bounds.Min.Y) < y-1.5*musicStaffSpace || float64(bounds.Max.Y) > y+1.5*musicStaffSpace || bounds.Min.X < 50-10 || bounds.Max.X > 50+10 {
+		t.Errorf("sharp bounds %v outside the expected region around (50, %.1f)", bounds, y)
+	}
+
+	//两条横线分别位于中心上下
+	above := getInkArea(graphics, image.Rect(44, int(y)-9, 56, int(y)-3))
+	below := getInkArea(graphics, image.Rect(44, int(y)+3, 56, int(y)+9))
+	if above < 10 || below < 10 {
+		t.Errorf("sharp bars: ink above %.1f below %.1f", above, below)
+	}
+
+	img, graphics = drawTestMusicGlyph(func(s *musicImage, dst *image.RGBA) {
+		rasterizer := newRasterizer(dst)
+		addMusicFlat(rasterizer, shapePoint{50, s.getStaffY(lineIndex)}, musicStaffSpace)
+		drawRasterizer(dst, rasterizer, image.Black)
+	})
+
+	//降号的符干向上超出一个线间距以上，符腹在所在线间内
+	bounds = getInkBounds(graphics)
+	if float64(bounds.Min.Y) > y-1.5*musicStaffSpace || float64(bounds.Max.Y) > y+musicStaffSpace {
+		t.Errorf("flat bounds %v, want stem above %.1f and bowl ending above %.1f", bounds, y-1.5*musicStaffSpace, y+musicStaffSpace)
+	}
+
+	center := shapePoint{50, y}
+	stemX := int(center[0] - musicStaffSpace*0.2)
+	bowl := getInkArea(graphics, image.Rect(stemX+2, int(y-musicStaffSpace/2), stemX+14, int(y+musicStaffSpace/2)))
+	left := getInkArea(graphics, image.Rect(0, 0, stemX-2, img.height))
+	if bowl < 10 || left > 0 {
+		t.Errorf("flat bowl ink %.1f right of the stem, %.1f left of the stem", bowl, left)
+	}
+}
+
+func TestMusicClefs(t *testing.T) {
+	for _, clef := range []string{MusicClefTreble, MusicClefBass} {
+		img, graphics := drawTestMusicGlyph(func(s *musicImage, dst *image.RGBA) {
+			s.SetMusicOption(MusicOption{Clef: clef})
+
+			clefImage, err := s.getMusicClefImage()
+			if err != nil {
+				t.Fatal(err)
+			}
+			draw.Draw(dst, dst.Bounds(), clefImage, image.ZP, draw.Over)
+		})
+
+		first, second, fourth, fifth := img.getStaffY(1), img.getStaffY(3), img.getStaffY(7), img.getStaffY(9)
+		bounds := getInkBounds(graphics)
+
+		//谱号位于谱号宽度之内
+		if bounds.Empty() || float64(bounds.Max.X) > musicClefWidth {
+			t.Errorf("%s clef bounds %v, want within %v pixels", clef, bounds, musicClefWidth)
+			continue
+		}
+
+		switch clef {
+		case MusicClefTreble:
+			//高音谱号上端高于第五线，尾部低于第一线，旋涡环绕第二线
+			if float64(bounds.Min.Y) > fifth || float64(bounds.Max.Y) < first+musicStaffSpace {
+				t.Errorf("treble clef bounds %v, want above %.1f and below %.1f", bounds, fifth, first+musicStaffSpace)
+			}
+
+			if area := getInkArea(graphics, image.Rect(0, int(second)-2, int(musicClefWidth), int(second)+3)); area < 5 {
+				t.Errorf("treble clef: ink %.1f on the second line", area)
+			}
+		case MusicClefBass:
+			//低音谱号不低于第一线，两个圆点分列第四线上下
+			if float64(bounds.Min.Y) < fifth-musicStaffSpace/2 || float64(bounds.Max.Y) > first {
+				t.Errorf("bass clef bounds %v, want between %.1f and %.1f", bounds, fifth-musicStaffSpace/2, first)
+			}
+
+			//与getMusicClefImage一致，起笔圆点位于x=12
+			origin := shapePoint{12, fourth}
+			dotX := int(origin[0] + 1.65*musicStaffSpace)
+			upper := getInkArea(graphics, image.Rect(dotX-2, int(fourth-musicStaffSpace/2)-2, dotX+3, int(fourth-musicStaffSpace/2)+3))
+			lower := getInkArea(graphics, image.Rect(dotX-2, int(fourth+musicStaffSpace/2)-2, dotX+3, int(fourth+musicStaffSpace/2)+3))
+			middle := getInkArea(graphics, image.Rect(dotX-1, int(fourth)-1, dotX+2, int(fourth)+2))
+			if upper < 5 || lower < 5 || middle > 0 {
+				t.Errorf("bass clef dots: ink above %.1f below %.1f on the fourth line %.1f", upper, lower, middle)
+			}
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package gcaptcha
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+/* ================================================================================
+ * 五线谱MIDI导出
+ * qq group: 582452342
+ * email   : 2091938785@qq.com
+ * author  : 美丽的地球啊 - mliu
+ * ================================================================================ */
+const (
+	midiMaxDelta = 0x0fffffff //变长delta时间最多4字节，即28位
+)
+
+type (
+	IMusicMidi interface {
+		GetMidi(option MusicMidiOption) ([]byte, error)
+	}
+
+	MusicMidiOption struct {
+		Tempo     int //速度（每分钟四分音符数），默认120，截断到4-60000000
+		Division  int //每四分音符的tick数，默认480
+		NoteTicks int //每个音的时值（tick），默认等于Division，即四分音符，最大0x0fffffff
+		RestTicks int //音之间的休止（tick），默认0，最大0x0fffffff
+		Velocity  int //力度1-127，默认90
+		Program   int //音色编号0-127，默认0（大钢琴）
+	}
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取标准MIDI文件数据（格式0，单音轨），音符序列与GetAudio一致
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *musicImage) GetMidi(option MusicMidiOption) ([]byte, error) {
+	option = s.getMidiOption(option)
+
+	notes, err := s.getMusicNotes()
+	if err != nil {
+		return nil, err
+	}
+
+	var track bytes.Buffer
+
+	//速度，单位为每四分音符微秒数
+	microseconds := 60000000 / option.Tempo
+	writeMidiEvent(&track, 0, 0xff, 0x51, 0x03, byte(microseconds>>16), byte(microseconds>>8), byte(microseconds))
+	writeMidiEvent(&track, 0, 0xc0, byte(option.Program))
+
+	for index, note := range notes {
+		delta := option.RestTicks
+		if index == 0 {
+			delta = 0
+		}
+
+		writeMidiEvent(&track, delta, 0x90, byte(note.key), byte(option.Velocity))
+		writeMidiEvent(&track, option.NoteTicks, 0x80, byte(note.key), 0)
+	}
+
+	//音轨结束
+	writeMidiEvent(&track, 0, 0xff, 0x2f, 0x00)
+
+	var buffer bytes.Buffer
+	header := struct {
+		Id       [4]byte
+		Size     uint32
+		Format   uint16
+		Tracks   uint16
+		Division uint16
+	}{
+		Id:       [4]byte{'M', 'T', 'h', 'd'},
+		Size:     6,
+		Format:   0,
+		Tracks:   1,
+		Division: uint16(option.Division),
+	}
+	if err := binary.Write(&buffer, binary.BigEndian, &header); err != nil {
+		return nil, err
+	}
+
+	buffer.WriteString("MTrk")
+	if err := binary.Write(&buffer, binary.BigEndian, uint32(track.Len())); err != nil {
+		return nil, err
+	}
+	buffer.Write(track.Bytes())
+
+	return buffer.Bytes(), nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 填充MIDI选项默认值，超出范围的值被截断
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *musicImage) getMidiOption(option MusicMidiOption) MusicMidiOption {
+	//速度事件只有3字节，每四分音符微秒数不能超过0xffffff，即速度至少为4
+	if option.Tempo <= 0 {
+		option.Tempo = 120
+	} else if option.Tempo < 4 {
+		option.Tempo = 4
+	} else if option.Tempo > 60000000 {
+		option.Tempo = 60000000
+	}
+
+	//division最高位为1时表示SMPTE时间格式
+	if option.Division <= 0 || option.Division > 0x7fff {
+		option.Division = 480
+	}
+
+	//超过4字节变长数值的delta会写出错误的音轨
+	if option.NoteTicks <= 0 {
+		option.NoteTicks = option.Division
+	} else if option.NoteTicks > midiMaxDelta {
+		option.NoteTicks = midiMaxDelta
+	}
+
+	if option.RestTicks < 0 {
+		option.RestTicks = 0
+	} else if option.RestTicks > midiMaxDelta {
+		option.RestTicks = midiMaxDelta
+	}
+
+	if option.Velocity <= 0 {
+		option.Velocity = 90
+	} else if option.Velocity > 127 {
+		option.Velocity = 127
+	}
+
+	if option.Program < 0 || option.Program > 127 {
+		option.Program = 0
+	}
+
+	return option
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 写入变长delta时间和事件数据
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func writeMidiEvent(buffer *bytes.Buffer, delta int, data ...byte) {
+	//变长数值：每字节7位，高位在前，除最后一字节外最高位置1
+	quantity := []byte{byte(delta & 0x7f)}
+	for delta >>= 7; delta > 0; delta >>= 7 {
+		quantity = append([]byte{byte(delta&0x7f) | 0x80}, quantity...)
+	}
+
+	buffer.Write(quantity)
+	buffer.Write(data)
+}
@@ -0,0 +1,118 @@
+package gcaptcha
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestMusicMidiTempo(t *testing.T) {
+	tests := []struct {
+		tempo            int
+		wantMicroseconds int
+	}{
+		{0, 500000},
+		{-1, 500000},
+		{1, 15000000},
+		{3, 15000000},
+		{4, 15000000},
+		{120, 500000},
+		{1000000000, 1},
+	}
+
+	for _, test := range tests {
+		img := NewMusicImage("", []string{"C", "D", "E", "F"}, "", 4).(*musicImage)
+		img.SetOption(ImageOption{Random: NewRandom(1)})
+
+		data, err := img.GetMidi(MusicMidiOption{Tempo: test.tempo})
+		if err != nil {
+			t.Fatalf("tempo %d: GetMidi: %v", test.tempo, err)
+		}
+
+		//MThd(14) + MTrk(4) + 长度(4)，首个事件为 delta 0, FF 51 03 tt tt tt
+		if len(data) < 29 || !bytes.Equal(data[22:26], []byte{0x00, 0xff, 0x51, 0x03}) {
+			t.Fatalf("tempo %d: missing tempo event: % x", test.tempo, data)
+		}
+
+		microseconds := int(data[26])<<16 | int(data[27])<<8 | int(data[28])
+		if microseconds != test.wantMicroseconds {
+			t.Errorf("tempo %d: %d microseconds per quarter, want %d", test.tempo, microseconds, test.wantMicroseconds)
+		}
+	}
+}
+
+func TestMusicMidiNotes(t *testing.T) {
+	img := NewMusicImage("", []string{"C", "D", "E", "F", "G", "A"}, "", 4).(*musicImage)
+	img.SetOption(ImageOption{Random: NewRandom(1)})
+
+	data, err := img.GetMidi(MusicMidiOption{Velocity: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notes, err := img.getMusicNotes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.HasPrefix(data, []byte("MThd\x00\x00\x00\x06\x00\x00\x00\x01\x01\xe0MTrk")) {
+		t.Fatalf("unexpected header: % x", data[:18])
+	}
+
+	//每个音符开：0x90 音高 力度（截断到127）
+	for _, note := range notes {
+		if !bytes.Contains(data, []byte{0x90, byte(note.key), 127}) {
+			t.Errorf("note %s (%d) missing note on", note.name, note.key)
+		}
+	}
+
+	if !bytes.HasSuffix(data, []byte{0x00, 0xff, 0x2f, 0x00}) {
+		t.Errorf("missing end of track: % x", data[len(data)-4:])
+	}
+}
+
+func TestMusicMidiTicks(t *testing.T) {
+	img := NewMusicImage("", []string{"C", "D", "E", "F"}, "", 2).(*musicImage)
+	img.SetOption(ImageOption{Random: NewRandom(1)})
+
+	tests := []struct {
+		noteTicks, restTicks int
+		wantNote, wantRest   int
+	}{
+		{0, -1, 480, 0},
+		{100, 50, 100, 50},
+		{midiMaxDelta, midiMaxDelta, midiMaxDelta, midiMaxDelta},
+		{midiMaxDelta + 1, midiMaxDelta + 1, midiMaxDelta, midiMaxDelta},
+		{mathMaxInt, mathMaxInt, midiMaxDelta, midiMaxDelta},
+	}
+
+	for _, test := range tests {
+		option := img.getMidiOption(MusicMidiOption{NoteTicks: test.noteTicks, RestTicks: test.restTicks})
+		if option.NoteTicks != test.wantNote || option.RestTicks != test.wantRest {
+			t.Errorf("ticks %d, %d: got %d, %d, want %d, %d", test.noteTicks, test.restTicks, option.NoteTicks, option.RestTicks, test.wantNote, test.wantRest)
+		}
+	}
+
+	data, err := img.GetMidi(MusicMidiOption{NoteTicks: mathMaxInt, RestTicks: mathMaxInt})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notes, err := img.getMusicNotes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//最大delta编码为4字节 ff ff ff 7f，音轨长度与实际数据一致
+	if !bytes.Contains(data, []byte{0xff, 0xff, 0xff, 0x7f, 0x80, byte(notes[0].key), 0}) {
+		t.Errorf("missing note off with the largest delta: % x", data)
+	}
+
+	if !bytes.Contains(data, []byte{0xff, 0xff, 0xff, 0x7f, 0x90, byte(notes[1].key)}) {
+		t.Errorf("missing note on with the largest delta: % x", data)
+	}
+
+	if length := int(binary.BigEndian.Uint32(data[18:22])); length != len(data)-22 {
+		t.Errorf("track length %d, want %d", length, len(data)-22)
+	}
+}
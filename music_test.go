@@ -0,0 +1,100 @@
+package gcaptcha
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 测试用的五线谱图，五线为直线，4个音符时每个音符占50像素宽
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func newTestMusicImage(seed int64, texts []string, count int, option MusicOption) *musicImage {
+	img := NewMusicImage("", texts, "", count).(*musicImage)
+	img.SetOption(ImageOption{
+		CellWidth:  60,
+		CellHeight: 200,
+		Padding:    10,
+		Random:     NewRandom(seed),
+	})
+
+	option.StaffWobble = -1
+	img.SetMusicOption(option)
+
+	return img
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 彩色墨迹的判断，五线和加线为黑色，音符为彩色
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func isMusicNoteInk(graphics *image.RGBA, x, y int) bool {
+	c := graphics.RGBAAt(x, y)
+	max := math.Max(float64(c.R), math.Max(float64(c.G), float64(c.B)))
+	min := math.Min(float64(c.R), math.Min(float64(c.G), float64(c.B)))
+
+	return max-min > 20
+}
+
+func TestMusicImageNotePositions(t *testing.T) {
+	texts := []string{"C", "D", "E", "F", "G", "A", "B"}
+	options := []MusicOption{
+		{},
+		{Clef: MusicClefBass},
+		{LedgerLines: 2},
+	}
+
+	for optionIndex, option := range options {
+		for seed := int64(1); seed <= 5; seed++ {
+			img := newTestMusicImage(seed, texts, 4, option)
+
+			raw, err := img.GetRawImage()
+			if err != nil {
+				t.Fatal(err)
+			}
+			graphics := raw.(*image.RGBA)
+
+			staves := img.GetStaffLines()
+			if len(staves) != 5 {
+				t.Fatalf("option %d seed %d: staff lines %v", optionIndex, seed, staves)
+			}
+
+			//线间索引1为最下方的第一线，每升高一个索引上移半个线间距
+			space := (staves[4] - staves[0]) / 4
+			radiusX, _ := getMusicHeadRadius(space)
+			step := (float64(img.width-2*img.option.Padding) - musicClefWidth) / float64(len(img.lines))
+
+			for index, line := range img.lines {
+				want := staves[4] - float64(line-1)*space/2
+
+				//符头所在行的彩色墨迹宽于符干，取这些行的中点为符头中心
+				minX := img.option.Padding + int(musicClefWidth+step*float64(index))
+				maxX := img.option.Padding + int(musicClefWidth+step*float64(index+1))
+				top, bottom := -1, -1
+				for y := 0; y < img.height; y++ {
+					count := 0
+					for x := minX; x < maxX; x++ {
+						if isMusicNoteInk(graphics, x, y) {
+							count++
+						}
+					}
+
+					if float64(count) >= radiusX {
+						if top < 0 {
+							top = y
+						}
+						bottom = y
+					}
+				}
+
+				if top < 0 {
+					t.Errorf("option %d seed %d: note %d on line %d not found", optionIndex, seed, index, line)
+					continue
+				}
+
+				if center := float64(top+bottom+1) / 2; math.Abs(center-want) > 2 {
+					t.Errorf("option %d seed %d: note %d on line %d centered at y %.1f, want %.1f", optionIndex, seed, index, line, center, want)
+				}
+			}
+		}
+	}
+}
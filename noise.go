@@ -0,0 +1,272 @@
+package gcaptcha
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+/* ================================================================================
+ * 干扰噪声层
+ * qq group: 582452342
+ * email   : 2091938785@qq.com
+ * author  : 美丽的地球啊 - mliu
+ * ================================================================================ */
+var (
+	defaultNoiseColors = []color.Color{
+		color.RGBA{120, 120, 50, 255},
+		color.RGBA{120, 132, 40, 255},
+		color.RGBA{90, 90, 90, 255},
+		color.RGBA{60, 90, 120, 255},
+	}
+)
+
+type (
+	//glyphs为画布坐标中每个字形的区域，非文字图片为空
+	INoise interface {
+		Draw(img *image.RGBA, glyphs []image.Rectangle, random IRandom)
+	}
+
+	//随机斑点
+	DotNoise struct {
+		Density float64       //每1000像素的斑点数
+		Radius  float64       //斑点半径，默认1
+		Colors  []color.Color //为空时使用默认颜色
+	}
+
+	//直线干扰线
+	LineNoise struct {
+		Count  int     //线条数量
+		Width  float64 //线宽，默认1
+		Colors []color.Color
+	}
+
+	//穿过文字的贝塞尔曲线，沿字形中心走向，无字形时随机横穿画布
+	CurveNoise struct {
+		Count       int     //曲线数量
+		Width       float64 //线宽，默认1.5
+		Jitter      float64 //经过字形中心时的随机偏移（像素），默认3
+		IsQuadratic bool    //使用单段二次曲线，默认逐字形连接的三次曲线
+		Colors      []color.Color
+	}
+
+	//空心圆
+	CircleNoise struct {
+		Count     int     //圆数量
+		RadiusMin float64 //半径范围，默认4-12
+		RadiusMax float64
+		Width     float64 //线宽，默认1
+		Colors    []color.Color
+	}
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 依次绘制噪声层
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func drawNoises(img *image.RGBA, noises []INoise, glyphs []image.Rectangle, random IRandom) {
+	for _, noise := range noises {
+		if noise != nil {
+			noise.Draw(img, glyphs, random)
+		}
+	}
+}
+
+func (s DotNoise) Draw(img *image.RGBA, glyphs []image.Rectangle, random IRandom) {
+	bounds := img.Bounds()
+	count := int(s.Density * float64(bounds.Dx()*bounds.Dy()) / 1000)
+	if count <= 0 {
+		return
+	}
+
+	radius := s.Radius
+	if radius <= 0 {
+		radius = 1
+	}
+
+	//按颜色分组光栅化
+	colors := getNoiseColors(s.Colors)
+	rasterizer := newRasterizer(img)
+
+	centers := make([][]shapePoint, len(colors))
+	for index := 0; index < count; index++ {
+		colorIndex := random.Intn(len(colors))
+		centers[colorIndex] = append(centers[colorIndex], shapePoint{
+			float64(bounds.Min.X) + random.Float64()*float64(bounds.Dx()),
+			float64(bounds.Min.Y) + random.Float64()*float64(bounds.Dy()),
+		})
+	}
+
+	for colorIndex, points := range centers {
+		if len(points) == 0 {
+			continue
+		}
+
+		rasterizer.Reset(bounds.Dx(), bounds.Dy())
+		for _, center := range points {
+			addPolygon(rasterizer, circlePoints(center, radius, false))
+		}
+		drawRasterizer(img, rasterizer, image.NewUniform(colors[colorIndex]))
+	}
+}
+
+func (s LineNoise) Draw(img *image.RGBA, glyphs []image.Rectangle, random IRandom) {
+	bounds := img.Bounds()
+	width := s.Width
+	if width <= 0 {
+		width = 1
+	}
+
+	colors := getNoiseColors(s.Colors)
+	for index := 0; index < s.Count; index++ {
+		//从左侧区域到右侧区域
+		start := shapePoint{
+			float64(bounds.Min.X) + random.Float64()*float64(bounds.Dx())*0.3,
+			float64(bounds.Min.Y) + random.Float64()*float64(bounds.Dy()),
+		}
+		end := shapePoint{
+			float64(bounds.Min.X) + float64(bounds.Dx())*(0.7+random.Float64()*0.3),
+			float64(bounds.Min.Y) + random.Float64()*float64(bounds.Dy()),
+		}
+
+		rasterizer := newRasterizer(img)
+		addPolyline(rasterizer, []shapePoint{start, end}, width)
+		drawRasterizer(img, rasterizer, image.NewUniform(colors[random.Intn(len(colors))]))
+	}
+}
+
+func (s CurveNoise) Draw(img *image.RGBA, glyphs []image.Rectangle, random IRandom) {
+	width := s.Width
+	if width <= 0 {
+		width = 1.5
+	}
+
+	jitter := s.Jitter
+	if jitter <= 0 {
+		jitter = 3
+	}
+
+	colors := getNoiseColors(s.Colors)
+	for index := 0; index < s.Count; index++ {
+		anchors := s.getAnchors(img.Bounds(), glyphs, jitter, random)
+
+		var points []shapePoint
+		if s.IsQuadratic || len(anchors) < 3 {
+			points = s.getQuadraticPoints(anchors)
+		} else {
+			points = s.getCubicPoints(anchors)
+		}
+
+		rasterizer := newRasterizer(img)
+		addPolyline(rasterizer, points, width)
+		drawRasterizer(img, rasterizer, image.NewUniform(colors[random.Intn(len(colors))]))
+	}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 曲线经过的锚点：首字形左侧、每个字形中心（加随机偏移）、末字形右侧
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s CurveNoise) getAnchors(bounds image.Rectangle, glyphs []image.Rectangle, jitter float64, random IRandom) []shapePoint {
+	if len(glyphs) == 0 {
+		centerY := float64(bounds.Min.Y) + float64(bounds.Dy())*randFloatRange(random, 0.3, 0.7)
+		amplitude := float64(bounds.Dy()) * 0.2
+
+		return []shapePoint{
+			{float64(bounds.Min.X), centerY + randFloatRange(random, -amplitude, amplitude)},
+			{float64(bounds.Min.X) + float64(bounds.Dx())/2, centerY + randFloatRange(random, -amplitude, amplitude)},
+			{float64(bounds.Max.X), centerY + randFloatRange(random, -amplitude, amplitude)},
+		}
+	}
+
+	anchors := make([]shapePoint, 0, len(glyphs)+2)
+
+	first, last := glyphs[0], glyphs[len(glyphs)-1]
+	anchors = append(anchors, shapePoint{
+		float64(first.Min.X) - float64(first.Dx())*randFloatRange(random, 0.5, 1),
+		float64(first.Min.Y+first.Max.Y)/2 + randFloatRange(random, -jitter, jitter)*2,
+	})
+
+	for _, glyph := range glyphs {
+		anchors = append(anchors, shapePoint{
+			float64(glyph.Min.X+glyph.Max.X)/2 + randFloatRange(random, -jitter, jitter),
+			float64(glyph.Min.Y+glyph.Max.Y)/2 + randFloatRange(random, -jitter, jitter),
+		})
+	}
+
+	anchors = append(anchors, shapePoint{
+		float64(last.Max.X) + float64(last.Dx())*randFloatRange(random, 0.5, 1),
+		float64(last.Min.Y+last.Max.Y)/2 + randFloatRange(random, -jitter, jitter)*2,
+	})
+
+	return anchors
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 经过首、中、末锚点的二次贝塞尔曲线
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s CurveNoise) getQuadraticPoints(anchors []shapePoint) []shapePoint {
+	p0, p2 := anchors[0], anchors[len(anchors)-1]
+	middle := anchors[len(anchors)/2]
+
+	//控制点使曲线在t=0.5时经过middle
+	p1 := shapePoint{2*middle[0] - (p0[0]+p2[0])/2, 2*middle[1] - (p0[1]+p2[1])/2}
+
+	return quadraticPoints(p0, p1, p2, 48)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 经过全部锚点的三次贝塞尔曲线（Catmull-Rom 转换）
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s CurveNoise) getCubicPoints(anchors []shapePoint) []shapePoint {
+	points := make([]shapePoint, 0)
+
+	for index := 0; index < len(anchors)-1; index++ {
+		p0 := anchors[int(math.Max(float64(index-1), 0))]
+		p1, p2 := anchors[index], anchors[index+1]
+		p3 := anchors[int(math.Min(float64(index+2), float64(len(anchors)-1)))]
+
+		c1 := shapePoint{p1[0] + (p2[0]-p0[0])/6, p1[1] + (p2[1]-p0[1])/6}
+		c2 := shapePoint{p2[0] - (p3[0]-p1[0])/6, p2[1] - (p3[1]-p1[1])/6}
+
+		segment := cubicPoints(p1, c1, c2, p2, 16)
+		if index > 0 {
+			segment = segment[1:]
+		}
+		points = append(points, segment...)
+	}
+
+	return points
+}
+
+func (s CircleNoise) Draw(img *image.RGBA, glyphs []image.Rectangle, random IRandom) {
+	bounds := img.Bounds()
+
+	radiusMin, radiusMax := s.RadiusMin, s.RadiusMax
+	if radiusMin <= 0 && radiusMax <= 0 {
+		radiusMin, radiusMax = 4, 12
+	}
+
+	width := s.Width
+	if width <= 0 {
+		width = 1
+	}
+
+	colors := getNoiseColors(s.Colors)
+	for index := 0; index < s.Count; index++ {
+		center := shapePoint{
+			float64(bounds.Min.X) + random.Float64()*float64(bounds.Dx()),
+			float64(bounds.Min.Y) + random.Float64()*float64(bounds.Dy()),
+		}
+
+		rasterizer := newRasterizer(img)
+		addRing(rasterizer, center, randFloatRange(random, radiusMin, radiusMax), width)
+		drawRasterizer(img, rasterizer, image.NewUniform(colors[random.Intn(len(colors))]))
+	}
+}
+
+func getNoiseColors(colors []color.Color) []color.Color {
+	if len(colors) == 0 {
+		return defaultNoiseColors
+	}
+
+	return colors
+}
@@ -0,0 +1,154 @@
+package gcaptcha
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"testing"
+)
+
+var (
+	testNoiseColors = []color.Color{color.Black}
+)
+
+func newTestNoiseImage(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.White, image.ZP, draw.Src)
+
+	return img
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 白底黑色噪声的覆盖面积（像素），抗锯齿边缘按覆盖比例计入
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func getInkArea(img *image.RGBA, rect image.Rectangle) float64 {
+	var area float64
+	rect = rect.Intersect(img.Bounds())
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			area += float64(255-img.RGBAAt(x, y).R) / 255
+		}
+	}
+
+	return area
+}
+
+func TestDotNoiseDensity(t *testing.T) {
+	const width, height = 200, 100
+
+	for _, density := range []float64{0, 2, 5, 10} {
+		img := newTestNoiseImage(width, height)
+		DotNoise{Density: density, Radius: 1, Colors: testNoiseColors}.Draw(img, nil, NewRandom(1))
+
+		//每个斑点面积约为πr²，低密度下重叠可忽略
+		want := density * width * height / 1000 * math.Pi
+		if area := getInkArea(img, img.Bounds()); math.Abs(area-want) > want*0.15+0.5 {
+			t.Errorf("density %v: ink area %.1f, want about %.1f", density, area, want)
+		}
+	}
+}
+
+func TestLineNoiseCount(t *testing.T) {
+	const width, height = 200, 100
+
+	for _, count := range []int{0, 1, 3} {
+		img := newTestNoiseImage(width, height)
+		LineNoise{Count: count, Width: 2, Colors: testNoiseColors}.Draw(img, nil, NewRandom(2))
+
+		//每条线从左侧30%到右侧30%，长度在0.4倍宽度与对角线之间
+		area := getInkArea(img, img.Bounds())
+		minArea := float64(count) * 2 * width * 0.4 * 0.8
+		maxArea := float64(count) * 2 * math.Hypot(width, height) * 1.1
+		if area < minArea || area > maxArea {
+			t.Errorf("count %d: ink area %.1f, want %.1f-%.1f", count, area, minArea, maxArea)
+		}
+
+		if count > 0 {
+			left := getInkArea(img, image.Rect(0, 0, width*3/10, height))
+			right := getInkArea(img, image.Rect(width*7/10, 0, width, height))
+			if left == 0 || right == 0 {
+				t.Errorf("count %d: lines do not cross the canvas, left %.1f right %.1f", count, left, right)
+			}
+		}
+	}
+}
+
+func TestCurveNoiseThroughGlyphs(t *testing.T) {
+	glyphs := []image.Rectangle{
+		image.Rect(20, 30, 40, 60),
+		image.Rect(60, 10, 80, 40),
+		image.Rect(100, 40, 120, 70),
+		image.Rect(140, 20, 160, 50),
+	}
+
+	for seed := int64(1); seed <= 10; seed++ {
+		img := newTestNoiseImage(200, 80)
+		CurveNoise{Count: 1, Width: 2, Jitter: 2, Colors: testNoiseColors}.Draw(img, glyphs, NewRandom(seed))
+
+		//曲线经过每个字形中心附近，偏移不超过Jitter
+		for _, glyph := range glyphs {
+			center := image.Pt((glyph.Min.X+glyph.Max.X)/2, (glyph.Min.Y+glyph.Max.Y)/2)
+			if getInkArea(img, image.Rectangle{center, center}.Inset(-4)) == 0 {
+				t.Errorf("seed %d: curve misses glyph %v", seed, glyph)
+			}
+		}
+	}
+
+	//二次曲线经过中间字形
+	img := newTestNoiseImage(200, 80)
+	CurveNoise{Count: 1, Width: 2, Jitter: 2, IsQuadratic: true, Colors: testNoiseColors}.Draw(img, glyphs, NewRandom(1))
+	middle := glyphs[len(glyphs)/2]
+	center := image.Pt((middle.Min.X+middle.Max.X)/2, (middle.Min.Y+middle.Max.Y)/2)
+	if getInkArea(img, image.Rectangle{center, center}.Inset(-4)) == 0 {
+		t.Errorf("quadratic curve misses the middle glyph %v", middle)
+	}
+
+	//无字形时横穿画布
+	img = newTestNoiseImage(200, 80)
+	CurveNoise{Count: 1, Width: 2, Colors: testNoiseColors}.Draw(img, nil, NewRandom(1))
+	if getInkArea(img, image.Rect(0, 0, 10, 80)) == 0 || getInkArea(img, image.Rect(190, 0, 200, 80)) == 0 {
+		t.Error("curve without glyphs does not cross the canvas")
+	}
+}
+
+func TestCircleNoiseArea(t *testing.T) {
+	img := newTestNoiseImage(200, 100)
+	CircleNoise{Count: 1, RadiusMin: 10, RadiusMax: 10, Width: 2, Colors: testNoiseColors}.Draw(img, nil, NewRandom(3))
+
+	//完整圆环面积为2πrw，圆心靠近边缘时会被裁剪
+	want := 2 * math.Pi * 10 * 2
+	if area := getInkArea(img, img.Bounds()); area <= 0 || area > want*1.1 {
+		t.Errorf("ring ink area %.1f, want at most %.1f", area, want)
+	}
+}
+
+func TestBezierPoints(t *testing.T) {
+	p0, p1, p2, p3 := shapePoint{0, 0}, shapePoint{10, 20}, shapePoint{30, 20}, shapePoint{40, 0}
+
+	quadratic := quadraticPoints(p0, p1, p3, 8)
+	if len(quadratic) != 9 || quadratic[0] != p0 || quadratic[8] != p3 {
+		t.Errorf("quadratic points %v do not start at %v and end at %v", quadratic, p0, p3)
+	}
+
+	//t=0.5时为 (p0+2p1+p2)/4
+	if middle := quadratic[4]; middle != (shapePoint{15, 10}) {
+		t.Errorf("quadratic middle %v, want [15 10]", middle)
+	}
+
+	cubic := cubicPoints(p0, p1, p2, p3, 8)
+	if len(cubic) != 9 || cubic[0] != p0 || cubic[8] != p3 {
+		t.Errorf("cubic points %v do not start at %v and end at %v", cubic, p0, p3)
+	}
+
+	//t=0.5时为 (p0+3p1+3p2+p3)/8
+	if middle := cubic[4]; middle != (shapePoint{20, 15}) {
+		t.Errorf("cubic middle %v, want [20 15]", middle)
+	}
+
+	for _, point := range circlePoints(shapePoint{5, 5}, 3, false) {
+		if distance := math.Hypot(point[0]-5, point[1]-5); math.Abs(distance-3) > 1e-9 {
+			t.Errorf("circle point %v at distance %v, want 3", point, distance)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package gcaptcha
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+)
+
+/* ================================================================================
+ * 图片编码
+ * qq group: 582452342
+ * email   : 2091938785@qq.com
+ * author  : 美丽的地球啊 - mliu
+ * ================================================================================ */
+var (
+	ErrImageFormat = errors.New("image: unsupported output format")
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 按输出选项编码图片，格式为空时按png编码，gif由支持动画的图片自行编码，
+ * 传入此处的gif和未知格式返回ErrImageFormat
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func encodeImage(img image.Image, output OutputOption) ([]byte, error) {
+	var imageBuffer bytes.Buffer
+
+	switch output.Format {
+	case ImageFormatJpeg:
+		quality := output.JpegQuality
+		if quality <= 0 {
+			quality = 80
+		}
+
+		if err := jpeg.Encode(&imageBuffer, img, &jpeg.Options{Quality: quality}); err != nil {
+			return nil, err
+		}
+	case "", ImageFormatPng:
+		encoder := &png.Encoder{
+			CompressionLevel: output.PngCompression,
+		}
+
+		if err := encoder.Encode(&imageBuffer, img); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrImageFormat, output.Format)
+	}
+
+	return imageBuffer.Bytes(), nil
+}
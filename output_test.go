@@ -0,0 +1,183 @@
+package gcaptcha
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"net/http"
+	"testing"
+)
+
+func TestEncodeImage(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+
+	tests := []struct {
+		format          string
+		wantContentType string
+		wantErr         error
+	}{
+		{"", "image/png", nil},
+		{ImageFormatPng, "image/png", nil},
+		{ImageFormatJpeg, "image/jpeg", nil},
+		{ImageFormatGif, "", ErrImageFormat},
+		{"bmp", "", ErrImageFormat},
+	}
+
+	for _, test := range tests {
+		data, err := encodeImage(img, OutputOption{Format: test.format})
+		if !errors.Is(err, test.wantErr) {
+			t.Errorf("format %q: err = %v, want %v", test.format, err, test.wantErr)
+			continue
+		}
+
+		if err == nil && http.DetectContentType(data) != test.wantContentType {
+			t.Errorf("format %q: content type %q, want %q", test.format, http.DetectContentType(data), test.wantContentType)
+		}
+	}
+}
+
+func TestGifOutput(t *testing.T) {
+	option := ImageOption{
+		CellWidth:  30,
+		CellHeight: 40,
+		FontSize:   20,
+		Random:     NewRandom(1),
+		Output:     OutputOption{Format: ImageFormatGif},
+	}
+
+	text := NewTextImage("", []string{"a", "b", "c"}, 2)
+	text.SetOption(option)
+	if data, err := text.GetImage(); err != nil || http.DetectContentType(data) != "image/gif" {
+		t.Errorf("text image gif: err %v", err)
+	}
+
+	slider := NewSliderImage("", SliderOption{})
+	slider.SetOption(option)
+	if _, err := slider.GetImage(); !errors.Is(err, ErrImageFormat) {
+		t.Errorf("slider image gif: err = %v, want ErrImageFormat", err)
+	}
+}
+
+func TestGifFramesHideGlyphs(t *testing.T) {
+	newImage := func() *textImage {
+		img := NewTextImage("", []string{"a", "b", "c", "d", "e", "f"}, 4).(*textImage)
+		img.SetOption(ImageOption{
+			CellWidth:  30,
+			CellHeight: 40,
+			FontSize:   20,
+			Random:     NewRandom(5),
+			Output:     OutputOption{Format: ImageFormatGif, FrameCount: 4},
+		})
+
+		return img
+	}
+
+	data, err := newImage().GetImage()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	animation, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//相同种子按相同顺序重新生成字形，得到每个字形在画布中的位置
+	img := newImage()
+	texts := img.GetText()
+	_, offsetPoint, err := img.getBaseImage()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	glyphImages, _, err := img.getGlyphImages(texts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cores := getGlyphCores(glyphImages)
+
+	isInk := func(c color.Color) bool {
+		r, g, b, _ := c.RGBA()
+		return r>>8 < 252 || g>>8 < 252 || b>>8 < 252
+	}
+
+	shown := make([]bool, len(glyphImages))
+	for frameIndex, frame := range animation.Image {
+		missing := 0
+		for glyphIndex, core := range cores {
+			if len(core) == 0 {
+				t.Fatalf("glyph %d has no pixels apart from its neighbours", glyphIndex)
+			}
+
+			//允许字形漂移1像素
+			inkCount := 0
+			for _, point := range core {
+				for dy := -1; dy <= 1; dy++ {
+					for dx := -1; dx <= 1; dx++ {
+						if isInk(frame.At(point.X+offsetPoint.X+dx, point.Y+offsetPoint.Y+dy)) {
+							inkCount++
+						}
+					}
+				}
+			}
+
+			if inkCount == 0 {
+				missing++
+			} else {
+				shown[glyphIndex] = true
+			}
+		}
+
+		if missing == 0 {
+			t.Errorf("frame %d shows every answer glyph", frameIndex)
+		}
+	}
+
+	for glyphIndex, isShown := range shown {
+		if !isShown {
+			t.Errorf("glyph %d is never shown", glyphIndex)
+		}
+	}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 字形的核心像素：笔画像素，且3像素内没有其它字形的笔画，
+ * 漂移1像素后其邻域仍不会落入其它字形，隐藏时必为背景色
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func getGlyphCores(glyphImages []*image.RGBA) [][]image.Point {
+	isGlyphInk := func(glyphImage *image.RGBA, x, y int) bool {
+		if !image.Pt(x, y).In(glyphImage.Bounds()) {
+			return false
+		}
+
+		return glyphImage.RGBAAt(x, y).A >= 128
+	}
+
+	cores := make([][]image.Point, len(glyphImages))
+	for glyphIndex, glyphImage := range glyphImages {
+		bounds := glyphImage.Bounds()
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				isCore := isGlyphInk(glyphImage, x, y)
+				for dy := -3; dy <= 3 && isCore; dy++ {
+					for dx := -3; dx <= 3 && isCore; dx++ {
+						for otherIndex, other := range glyphImages {
+							if otherIndex != glyphIndex && isGlyphInk(other, x+dx, y+dy) {
+								isCore = false
+							}
+						}
+					}
+				}
+
+				if isCore {
+					cores[glyphIndex] = append(cores[glyphIndex], image.Pt(x, y))
+				}
+			}
+		}
+	}
+
+	return cores
+}
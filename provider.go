@@ -0,0 +1,119 @@
+package gcaptcha
+
+import (
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io/fs"
+	"os"
+	"path"
+	"sync"
+)
+
+import (
+	"github.com/sanxia/glib"
+)
+
+/* ================================================================================
+ * 单元格图片源
+ * qq group: 582452342
+ * email   : 2091938785@qq.com
+ * author  : 美丽的地球啊 - mliu
+ * ================================================================================ */
+type (
+	//name格式为 GridItem.Path/文件名，不含扩展名
+	ICellImageProvider interface {
+		GetImage(name string) (image.Image, error)
+	}
+
+	fileCellProvider struct {
+		root string
+	}
+
+	fsCellProvider struct {
+		fsys fs.FS
+		root string
+	}
+
+	memoryCellProvider struct {
+		images map[string]image.Image
+		mutex  sync.RWMutex
+	}
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 初始化磁盘图片源，读取 root/name.png
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func NewFileCellProvider(root string) ICellImageProvider {
+	return &fileCellProvider{
+		root: root,
+	}
+}
+
+func (s *fileCellProvider) GetImage(name string) (image.Image, error) {
+	filename := fmt.Sprintf("%s%s%s.png", s.root, string(os.PathSeparator), name)
+
+	return glib.GetImageFile(glib.GetAbsolutePath(filename))
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 初始化fs.FS图片源（如embed.FS），读取 root/name.png
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func NewFSCellProvider(fsys fs.FS, root string) ICellImageProvider {
+	return &fsCellProvider{
+		fsys: fsys,
+		root: root,
+	}
+}
+
+func (s *fsCellProvider) GetImage(name string) (image.Image, error) {
+	file, err := s.fsys.Open(path.Join(s.root, name+".png"))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return img, nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 初始化内存图片源，键为 GridItem.Path/文件名
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func NewMemoryCellProvider(images map[string]image.Image) *memoryCellProvider {
+	memoryCellProvider := &memoryCellProvider{
+		images: make(map[string]image.Image, len(images)),
+	}
+
+	for name, img := range images {
+		memoryCellProvider.images[name] = img
+	}
+
+	return memoryCellProvider
+}
+
+func (s *memoryCellProvider) GetImage(name string) (image.Image, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	img, ok := s.images[name]
+	if !ok {
+		return nil, fmt.Errorf("memory cell provider: image %s not found", name)
+	}
+
+	return img, nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 添加内存图片
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *memoryCellProvider) SetImage(name string, img image.Image) {
+	s.mutex.Lock()
+	s.images[name] = img
+	s.mutex.Unlock()
+}
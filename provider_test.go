@@ -0,0 +1,95 @@
+package gcaptcha
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestCellProviders(t *testing.T) {
+	pngFile := newTestPngFile(t)
+	badFile := []byte("not a png")
+
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "cat"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "cat", "1.png"), pngFile.Data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "cat", "2.png"), badFile, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fsys := fstest.MapFS{
+		"grid/cat/1.png": pngFile,
+		"grid/cat/2.png": &fstest.MapFile{Data: badFile},
+	}
+
+	providers := []struct {
+		name     string
+		provider ICellImageProvider
+	}{
+		{"file", NewFileCellProvider(root)},
+		{"fs", NewFSCellProvider(fsys, "grid")},
+	}
+
+	for _, test := range providers {
+		img, err := test.provider.GetImage("cat/1")
+		if err != nil {
+			t.Fatalf("%s: GetImage: %v", test.name, err)
+		}
+
+		if size := img.Bounds().Size(); size != image.Pt(8, 8) {
+			t.Errorf("%s: image size %v, want 8x8", test.name, size)
+		}
+
+		if _, err := test.provider.GetImage("cat/3"); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("%s: missing image err = %v, want fs.ErrNotExist", test.name, err)
+		}
+
+		if img, err := test.provider.GetImage("cat/2"); err == nil || img != nil {
+			t.Errorf("%s: undecodable image = %v, %v, want an error", test.name, img, err)
+		}
+	}
+
+	//根目录之外的文件不可读取
+	if _, err := NewFSCellProvider(fsys, "other").GetImage("cat/1"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("fs: image outside root err = %v, want fs.ErrNotExist", err)
+	}
+}
+
+func TestMemoryCellProvider(t *testing.T) {
+	cat := image.NewUniform(color.RGBA{255, 0, 0, 255})
+	dog := image.NewRGBA(image.Rect(0, 0, 4, 4))
+
+	images := map[string]image.Image{"cat/1": cat}
+	provider := NewMemoryCellProvider(images)
+
+	//构造后修改传入的map不影响图片源
+	images["cat/1"] = dog
+	images["cat/2"] = dog
+
+	if img, err := provider.GetImage("cat/1"); err != nil || img != image.Image(cat) {
+		t.Errorf("preloaded image = %v, %v, want %v", img, err, cat)
+	}
+
+	if img, err := provider.GetImage("cat/2"); err == nil || img != nil {
+		t.Errorf("missing image = %v, %v, want an error", img, err)
+	}
+
+	provider.SetImage("dog/1", dog)
+	if img, err := provider.GetImage("dog/1"); err != nil || img != image.Image(dog) {
+		t.Errorf("added image = %v, %v, want %v", img, err, dog)
+	}
+
+	provider.SetImage("cat/1", dog)
+	if img, _ := provider.GetImage("cat/1"); img != image.Image(dog) {
+		t.Errorf("replaced image = %v, want %v", img, dog)
+	}
+}
@@ -0,0 +1,137 @@
+package gcaptcha
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"math/big"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+/* ================================================================================
+ * 随机数源
+ * qq group: 582452342
+ * email   : 2091938785@qq.com
+ * author  : 美丽的地球啊 - mliu
+ * ================================================================================ */
+var (
+	defaultRandom IRandom = NewLockedRandom(time.Now().UnixNano())
+	secureRandom  IRandom = NewSecureRandom()
+)
+
+type (
+	//*rand.Rand 实现了该接口
+	IRandom interface {
+		Intn(n int) int
+		Float64() float64
+	}
+
+	lockedRandom struct {
+		rnd   *rand.Rand
+		mutex sync.Mutex
+	}
+
+	cryptoRandom struct{}
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 固定种子的随机数源，相同种子生成完全相同的图片和答案，非并发安全
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func NewRandom(seed int64) IRandom {
+	return rand.New(rand.NewSource(seed))
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 并发安全的随机数源
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func NewLockedRandom(seed int64) IRandom {
+	return &lockedRandom{
+		rnd: rand.New(rand.NewSource(seed)),
+	}
+}
+
+func (s *lockedRandom) Intn(n int) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.rnd.Intn(n)
+}
+
+func (s *lockedRandom) Float64() float64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.rnd.Float64()
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 基于crypto/rand的随机数源，不可预测、并发安全，速度较慢
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func NewSecureRandom() IRandom {
+	return cryptoRandom{}
+}
+
+func (s cryptoRandom) Intn(n int) int {
+	if n <= 0 {
+		panic("invalid argument to Intn")
+	}
+
+	value, err := crand.Int(crand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		panic("crypto/rand: " + err.Error())
+	}
+
+	return int(value.Int64())
+}
+
+func (s cryptoRandom) Float64() float64 {
+	var data [8]byte
+	if _, err := crand.Read(data[:]); err != nil {
+		panic("crypto/rand: " + err.Error())
+	}
+
+	//取53位尾数
+	return float64(binary.BigEndian.Uint64(data[:])>>11) / (1 << 53)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * min,max范围内的随机数，不含max，max不大于min时返回min
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func randIntRange(random IRandom, min, max int) int {
+	if max <= min {
+		return min
+	}
+
+	return min + random.Intn(max-min)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * min,max范围内的随机浮点数
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func randFloatRange(random IRandom, min, max float64) float64 {
+	if max <= min {
+		return min
+	}
+
+	return min + random.Float64()*(max-min)
+}
+
+func getRandom(random IRandom) IRandom {
+	if random == nil {
+		return defaultRandom
+	}
+
+	return random
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取答案选择随机数源，安全模式下使用crypto/rand
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func getAnswerRandom(random IRandom, isSecure bool) IRandom {
+	if isSecure {
+		return secureRandom
+	}
+
+	return getRandom(random)
+}
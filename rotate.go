@@ -0,0 +1,207 @@
+package gcaptcha
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+	"strconv"
+)
+
+import (
+	xdraw "golang.org/x/image/draw"
+)
+
+/* ================================================================================
+ * 旋转摆正图片
+ * qq group: 582452342
+ * email   : 2091938785@qq.com
+ * author  : 美丽的地球啊 - mliu
+ * ================================================================================ */
+type (
+	rotateImage struct {
+		title        string
+		names        []string //外部数据源，格式同单元格图片名 GridItem.Path/文件名
+		option       ImageOption
+		rotateOption RotateOption
+		name         string //当前图片名
+		angle        int    //图片顺时针旋转的角度
+		isGenerated  bool
+		generateErr  error
+	}
+
+	RotateOption struct {
+		Size      int                //圆形图片直径，默认160
+		MinAngle  int                //最小旋转角度，图片与正向至少相差该角度，默认30
+		Tolerance float64            //角度容差（度），默认8
+		ImagePath string             //图片目录，同网格图片
+		Provider  ICellImageProvider //图片源，为空时读取ImagePath目录
+	}
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 初始化旋转摆正图，从names中随机选择一张图片裁剪为圆形并随机旋转，
+ * 用户需顺时针旋转GetText中的角度使图片摆正
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func NewRotateImage(title string, names []string, option RotateOption) *rotateImage {
+	rotateImage := &rotateImage{
+		title:        title,
+		names:        names,
+		rotateOption: option,
+	}
+
+	if rotateImage.rotateOption.Size <= 0 {
+		rotateImage.rotateOption.Size = 160
+	}
+
+	if rotateImage.rotateOption.MinAngle <= 0 {
+		rotateImage.rotateOption.MinAngle = 30
+	}
+
+	if rotateImage.rotateOption.Tolerance <= 0 {
+		rotateImage.rotateOption.Tolerance = 8
+	}
+
+	return rotateImage
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取标题
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *rotateImage) GetTitle() string {
+	return s.title
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取摆正需要顺时针旋转的角度
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *rotateImage) GetText() []string {
+	if err := s.generate(); err != nil {
+		return nil
+	}
+
+	return []string{strconv.Itoa((360 - s.angle) % 360)}
+}
+
+func (s *rotateImage) SetOption(option ImageOption) {
+	s.option = option
+}
+
+func (s *rotateImage) getAnswerKind() string {
+	return answerKindAngle
+}
+
+func (s *rotateImage) getAnswerTexts() []string {
+	return s.GetText()
+}
+
+func (s *rotateImage) getAnswerTolerance() float64 {
+	return s.rotateOption.Tolerance
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 校验用户顺时针旋转的角度
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *rotateImage) VerifyAngle(angle float64) bool {
+	return newCaptchaAnswer(s).isMatch([]string{strconv.FormatFloat(angle, 'f', -1, 64)})
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 随机选择图片和旋转角度，首次获取答案或图片时执行
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *rotateImage) generate() error {
+	if s.isGenerated {
+		return s.generateErr
+	}
+	s.isGenerated = true
+
+	if len(s.names) == 0 {
+		s.generateErr = fmt.Errorf("rotate image: no images")
+		return s.generateErr
+	}
+
+	minAngle := s.rotateOption.MinAngle
+	if minAngle > 180 {
+		minAngle = 180
+	}
+
+	random := s.answerRandom()
+	s.name = s.names[random.Intn(len(s.names))]
+	s.angle = randIntRange(random, minAngle, 360-minAngle+1)
+
+	return nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取图片数据，按Output选择的格式编码
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *rotateImage) GetImage() ([]byte, error) {
+	img, err := s.GetRawImage()
+	if err != nil {
+		return nil, err
+	}
+
+	return encodeImage(img, s.option.Output)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取未编码的图片，圆形以外透明
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *rotateImage) GetRawImage() (image.Image, error) {
+	if err := s.generate(); err != nil {
+		return nil, err
+	}
+
+	provider := s.rotateOption.Provider
+	if provider == nil {
+		provider = NewFileCellProvider(s.rotateOption.ImagePath)
+	}
+
+	sourceImage, err := provider.GetImage(s.name)
+	if err != nil {
+		return nil, err
+	}
+
+	size := s.rotateOption.Size
+	bounds := image.Rect(0, 0, size, size)
+
+	//居中裁剪为正方形并缩放
+	squareImage := image.NewRGBA(bounds)
+	xdraw.BiLinear.Scale(squareImage, bounds, sourceImage, coverRect(sourceImage.Bounds(), bounds.Size()), xdraw.Src, nil)
+
+	//绕中心双线性旋转，内切圆始终被完整覆盖，边缘不会露出旋转痕迹
+	rotatedImage := image.NewRGBA(bounds)
+	radian := float64(s.angle) * math.Pi / 180
+	sin, cos := math.Sincos(radian)
+	center := [2]float64{float64(size) / 2, float64(size) / 2}
+	drawTransformed(rotatedImage, squareImage, center, center, [4]float64{cos, -sin, sin, cos})
+
+	//噪声和滤镜在圆形裁剪前执行
+	drawNoises(rotatedImage, s.option.Noises, nil, s.random())
+	rotatedImage = applyFilters(rotatedImage, s.option.Filters, s.random())
+
+	//抗锯齿圆形遮罩，略小于边长避免采样到画布边缘
+	mask := image.NewAlpha(bounds)
+	rasterizer := newRasterizer(mask)
+	addPolygon(rasterizer, circlePoints(shapePoint(center), float64(size)/2-1, false))
+	drawRasterizer(mask, rasterizer, image.Opaque)
+
+	graphics := image.NewRGBA(bounds)
+	draw.DrawMask(graphics, bounds, rotatedImage, image.ZP, mask, image.ZP, draw.Src)
+
+	return graphics, nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取随机数源
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *rotateImage) random() IRandom {
+	return getRandom(s.option.Random)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取答案选择随机数源
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *rotateImage) answerRandom() IRandom {
+	return getAnswerRandom(s.option.Random, s.option.IsSecure)
+}
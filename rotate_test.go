@@ -0,0 +1,115 @@
+package gcaptcha
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strconv"
+	"testing"
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 上半红色、下半蓝色的测试图片，便于判断旋转方向
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func newTestRotateImage(seed int64) *rotateImage {
+	source := image.NewRGBA(image.Rect(0, 0, 80, 80))
+	draw.Draw(source, image.Rect(0, 0, 80, 40), image.NewUniform(color.RGBA{255, 0, 0, 255}), image.ZP, draw.Src)
+	draw.Draw(source, image.Rect(0, 40, 80, 80), image.NewUniform(color.RGBA{0, 0, 255, 255}), image.ZP, draw.Src)
+
+	img := NewRotateImage("", []string{"photo/1"}, RotateOption{
+		Size:     80,
+		Provider: NewMemoryCellProvider(map[string]image.Image{"photo/1": source}),
+	})
+	img.SetOption(ImageOption{Random: NewRandom(seed)})
+
+	return img
+}
+
+func TestRotateImageAnswer(t *testing.T) {
+	for seed := int64(1); seed <= 100; seed++ {
+		img := newTestRotateImage(seed)
+
+		answers := img.GetText()
+		if len(answers) != 1 {
+			t.Fatalf("seed %d: answers %v", seed, answers)
+		}
+
+		if img.angle < img.rotateOption.MinAngle || img.angle > 360-img.rotateOption.MinAngle {
+			t.Errorf("seed %d: angle %d within %d degrees of upright", seed, img.angle, img.rotateOption.MinAngle)
+		}
+
+		if want := strconv.Itoa((360 - img.angle) % 360); answers[0] != want {
+			t.Errorf("seed %d: angle %d answer %s, want %s", seed, img.angle, answers[0], want)
+		}
+	}
+
+	if _, err := NewRotateImage("", nil, RotateOption{}).GetImage(); err == nil {
+		t.Error("GetImage succeeded without images")
+	}
+}
+
+func TestRotateImageVerifyAngle(t *testing.T) {
+	img := newTestRotateImage(1)
+	img.GetText()
+
+	//图片顺时针旋转了3度，需再顺时针旋转357度摆正
+	img.angle = 3
+
+	tests := []struct {
+		angle float64
+		want  bool
+	}{
+		{357, true},
+		{360 + 357, true},
+		{-3, true},
+		{350, true},
+		{5, true},
+		{348, false},
+		{6, false},
+		{180, false},
+		{math.NaN(), false},
+	}
+
+	for _, test := range tests {
+		if got := img.VerifyAngle(test.angle); got != test.want {
+			t.Errorf("VerifyAngle(%v) = %v, want %v", test.angle, got, test.want)
+		}
+	}
+}
+
+func TestRotateImagePixels(t *testing.T) {
+	img := newTestRotateImage(1)
+	img.GetText()
+	img.angle = 90
+
+	raw, err := img.GetRawImage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	graphics := raw.(*image.RGBA)
+
+	//圆形以外透明，圆内不透明
+	size := float64(img.rotateOption.Size)
+	for y := 0; y < img.rotateOption.Size; y++ {
+		for x := 0; x < img.rotateOption.Size; x++ {
+			distance := math.Hypot(float64(x)+0.5-size/2, float64(y)+0.5-size/2)
+			alpha := graphics.RGBAAt(x, y).A
+
+			if distance > size/2 && alpha != 0 {
+				t.Fatalf("pixel (%d, %d) outside the disc has alpha %d", x, y, alpha)
+			}
+
+			if distance < size/2-2 && alpha != 255 {
+				t.Fatalf("pixel (%d, %d) inside the disc has alpha %d", x, y, alpha)
+			}
+		}
+	}
+
+	//顺时针旋转90度后原来的上半部分在右侧
+	right := graphics.RGBAAt(70, 40)
+	left := graphics.RGBAAt(10, 40)
+	if right.R < 200 || right.B > 50 || left.B < 200 || left.R > 50 {
+		t.Errorf("rotated 90 degrees clockwise: right %v, left %v, want red on the right and blue on the left", right, left)
+	}
+}
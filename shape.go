@@ -0,0 +1,157 @@
+package gcaptcha
+
+import (
+	"image"
+	"image/draw"
+	"math"
+)
+
+import (
+	"golang.org/x/image/vector"
+)
+
+/* ================================================================================
+ * 抗锯齿矢量图形
+ * qq group: 582452342
+ * email   : 2091938785@qq.com
+ * author  : 美丽的地球啊 - mliu
+ * ================================================================================ */
+type (
+	shapePoint [2]float64
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 初始化与画布同尺寸的光栅器
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func newRasterizer(dst draw.Image) *vector.Rasterizer {
+	size := dst.Bounds().Size()
+
+	return vector.NewRasterizer(size.X, size.Y)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 将光栅器内容以src叠加到画布
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func drawRasterizer(dst draw.Image, rasterizer *vector.Rasterizer, src image.Image) {
+	bounds := dst.Bounds()
+	rasterizer.Draw(dst, bounds, src, bounds.Min)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 添加闭合多边形路径
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func addPolygon(rasterizer *vector.Rasterizer, points []shapePoint) {
+	if len(points) < 3 {
+		return
+	}
+
+	rasterizer.MoveTo(float32(points[0][0]), float32(points[0][1]))
+	for _, point := range points[1:] {
+		rasterizer.LineTo(float32(point[0]), float32(point[1]))
+	}
+	rasterizer.ClosePath()
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 添加折线描边路径，每段为一个矩形，拐点补圆
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func addPolyline(rasterizer *vector.Rasterizer, points []shapePoint, width float64) {
+	half := width / 2
+
+	for index := 1; index < len(points); index++ {
+		start, end := points[index-1], points[index]
+
+		dx, dy := end[0]-start[0], end[1]-start[1]
+		length := math.Hypot(dx, dy)
+		if length == 0 {
+			continue
+		}
+
+		//法线
+		nx, ny := -dy/length*half, dx/length*half
+
+		//与circlePoints同向，重叠处不会相互抵消
+		addPolygon(rasterizer, []shapePoint{
+			{start[0] - nx, start[1] - ny},
+			{end[0] - nx, end[1] - ny},
+			{end[0] + nx, end[1] + ny},
+			{start[0] + nx, start[1] + ny},
+		})
+
+		if index < len(points)-1 && width > 1.5 {
+			addPolygon(rasterizer, circlePoints(end, half, false))
+		}
+	}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 添加圆环路径，外圈顺时针内圈逆时针
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func addRing(rasterizer *vector.Rasterizer, center shapePoint, radius, width float64) {
+	addPolygon(rasterizer, circlePoints(center, radius+width/2, false))
+
+	if innerRadius := radius - width/2; innerRadius > 0 {
+		addPolygon(rasterizer, circlePoints(center, innerRadius, true))
+	}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 椭圆轮廓点，angle为旋转角度（弧度）
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func ellipsePoints(center shapePoint, radiusX, radiusY, angle float64, isReverse bool) []shapePoint {
+	count := int(math.Max(radiusX, radiusY)*2) + 12
+	sin, cos := math.Sincos(angle)
+
+	points := make([]shapePoint, 0, count)
+	for index := 0; index < count; index++ {
+		theta := 2 * math.Pi * float64(index) / float64(count)
+		if isReverse {
+			theta = -theta
+		}
+
+		x, y := radiusX*math.Cos(theta), radiusY*math.Sin(theta)
+		points = append(points, shapePoint{center[0] + x*cos - y*sin, center[1] + x*sin + y*cos})
+	}
+
+	return points
+}
+
+func circlePoints(center shapePoint, radius float64, isReverse bool) []shapePoint {
+	return ellipsePoints(center, radius, radius, 0, isReverse)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 二次贝塞尔曲线采样点
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func quadraticPoints(p0, p1, p2 shapePoint, count int) []shapePoint {
+	points := make([]shapePoint, 0, count+1)
+	for index := 0; index <= count; index++ {
+		t := float64(index) / float64(count)
+		a, b, c := (1-t)*(1-t), 2*(1-t)*t, t*t
+
+		points = append(points, shapePoint{
+			a*p0[0] + b*p1[0] + c*p2[0],
+			a*p0[1] + b*p1[1] + c*p2[1],
+		})
+	}
+
+	return points
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 三次贝塞尔曲线采样点
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func cubicPoints(p0, p1, p2, p3 shapePoint, count int) []shapePoint {
+	points := make([]shapePoint, 0, count+1)
+	for index := 0; index <= count; index++ {
+		t := float64(index) / float64(count)
+		a, b, c, d := (1-t)*(1-t)*(1-t), 3*(1-t)*(1-t)*t, 3*(1-t)*t*t, t*t*t
+
+		points = append(points, shapePoint{
+			a*p0[0] + b*p1[0] + c*p2[0] + d*p3[0],
+			a*p0[1] + b*p1[1] + c*p2[1] + d*p3[1],
+		})
+	}
+
+	return points
+}
@@ -0,0 +1,398 @@
+package gcaptcha
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"strconv"
+)
+
+/* ================================================================================
+ * 滑块拼图图片
+ * qq group: 582452342
+ * email   : 2091938785@qq.com
+ * author  : 美丽的地球啊 - mliu
+ * ================================================================================ */
+const (
+	slideTrackMinPoints   = 5   //轨迹最少点数
+	slideTrackMinDuration = 200 //轨迹最短耗时（毫秒）
+	slideTrackMinOffset   = 1.0 //轨迹偏离匀速直线的最小距离（像素）
+)
+
+type (
+	sliderImage struct {
+		title        string
+		option       ImageOption
+		sliderOption SliderOption
+		offsetX      int //拼图在背景中的位置，即期望答案
+		offsetY      int
+		pieceImage   *image.RGBA
+		isGenerated  bool
+	}
+
+	SliderOption struct {
+		Width        int     //背景宽度，默认300
+		Height       int     //背景高度，默认160
+		PieceSize    int     //拼图主体边长，不含凸起，默认44
+		Tolerance    float64 //x方向容差（像素），默认4
+		RequireTrack bool    //必须附带拖动轨迹，未附带时校验失败
+	}
+
+	//拖动轨迹点，坐标为相对拖动起点的像素，T为毫秒时间戳
+	SlidePoint struct {
+		X float64 `json:"x"`
+		Y float64 `json:"y"`
+		T int64   `json:"t"`
+	}
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 初始化滑块拼图，背景图使用ImageOption.Backgroud，为空时使用随机渐变
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func NewSliderImage(title string, option SliderOption) *sliderImage {
+	sliderImage := &sliderImage{
+		title:        title,
+		sliderOption: option,
+	}
+
+	if sliderImage.sliderOption.Width <= 0 {
+		sliderImage.sliderOption.Width = 300
+	}
+
+	if sliderImage.sliderOption.Height <= 0 {
+		sliderImage.sliderOption.Height = 160
+	}
+
+	if sliderImage.sliderOption.PieceSize <= 0 {
+		sliderImage.sliderOption.PieceSize = 44
+	}
+
+	if sliderImage.sliderOption.Tolerance <= 0 {
+		sliderImage.sliderOption.Tolerance = 4
+	}
+
+	return sliderImage
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取标题
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *sliderImage) GetTitle() string {
+	return s.title
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取期望的拼图x偏移
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *sliderImage) GetText() []string {
+	s.generate()
+
+	return []string{strconv.Itoa(s.offsetX)}
+}
+
+func (s *sliderImage) SetOption(option ImageOption) {
+	s.option = option
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取拼图在背景中的y坐标，拼图需在此高度水平拖动
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *sliderImage) GetPieceY() int {
+	s.generate()
+
+	return s.offsetY
+}
+
+func (s *sliderImage) getAnswerKind() string {
+	return answerKindSlide
+}
+
+func (s *sliderImage) getAnswerTexts() []string {
+	return s.GetText()
+}
+
+func (s *sliderImage) getAnswerTolerance() float64 {
+	return s.sliderOption.Tolerance
+}
+
+func (s *sliderImage) isTrackRequired() bool {
+	return s.sliderOption.RequireTrack
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 校验拖动到的x坐标，track不为空或RequireTrack时同时校验拖动轨迹
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *sliderImage) VerifySlide(x float64, track []SlidePoint) bool {
+	answers := []string{strconv.FormatFloat(x, 'f', -1, 64)}
+	for _, point := range track {
+		answers = append(answers, strconv.FormatFloat(point.X, 'f', -1, 64)+","+
+			strconv.FormatFloat(point.Y, 'f', -1, 64)+","+
+			strconv.FormatInt(point.T, 10))
+	}
+
+	return newCaptchaAnswer(s).isMatch(answers)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 随机生成拼图位置，首次获取答案或图片时执行，
+ * x避开拼图初始所在的左侧区域
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *sliderImage) generate() {
+	if s.isGenerated {
+		return
+	}
+	s.isGenerated = true
+
+	pieceSize := s.getPieceBounds().Dx()
+	margin := s.sliderOption.PieceSize / 4
+
+	random := s.answerRandom()
+	s.offsetX = randIntRange(random, pieceSize+margin, s.sliderOption.Width-pieceSize-margin+1)
+	s.offsetY = randIntRange(random, margin, s.sliderOption.Height-pieceSize-margin+1)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取图片数据，按Output选择的格式编码
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *sliderImage) GetImage() ([]byte, error) {
+	img, err := s.GetRawImage()
+	if err != nil {
+		return nil, err
+	}
+
+	return encodeImage(img, s.option.Output)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取未编码的带缺口背景图
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *sliderImage) GetRawImage() (image.Image, error) {
+	s.generate()
+
+	graphics := image.NewRGBA(image.Rect(0, 0, s.sliderOption.Width, s.sliderOption.Height))
+
+	//背景图
+	if s.option.Backgroud != "" {
+		if err := drawBackground(graphics, s.option.Backgroud); err != nil {
+			return nil, err
+		}
+	} else {
+		s.drawGradient(graphics)
+	}
+
+	//噪声，拼图与缺口保持一致，不使用滤镜
+	drawNoises(graphics, s.option.Noises, nil, s.random())
+
+	mask := s.getPieceMask()
+	edge := getMaskEdge(mask)
+	offsetPoint := image.Point{s.offsetX, s.offsetY}
+
+	//拼图取自原背景
+	s.pieceImage = image.NewRGBA(mask.Bounds())
+	draw.DrawMask(s.pieceImage, s.pieceImage.Bounds(), graphics, offsetPoint, mask, image.ZP, draw.Src)
+	draw.DrawMask(s.pieceImage, s.pieceImage.Bounds(), image.NewUniform(color.NRGBA{255, 255, 255, 220}), image.ZP, edge, image.ZP, draw.Over)
+
+	//缺口加暗并描边
+	holeRect := mask.Bounds().Add(offsetPoint)
+	draw.DrawMask(graphics, holeRect, image.NewUniform(color.RGBA{0, 0, 0, 130}), image.ZP, mask, image.ZP, draw.Over)
+	draw.DrawMask(graphics, holeRect, image.NewUniform(color.NRGBA{255, 255, 255, 160}), image.ZP, edge, image.ZP, draw.Over)
+
+	return graphics, nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取带透明通道的拼图数据，GetImage后有效
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *sliderImage) GetPieceImage() ([]byte, error) {
+	var imageBuffer bytes.Buffer
+
+	if s.pieceImage == nil {
+		if _, err := s.GetRawImage(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := png.Encode(&imageBuffer, s.pieceImage); err != nil {
+		return nil, err
+	}
+
+	return imageBuffer.Bytes(), nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 拼图外接区域，主体上方和右侧各有一个凸起
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *sliderImage) getPieceBounds() image.Rectangle {
+	size := s.sliderOption.PieceSize + s.getKnobRadius() + 2
+
+	return image.Rect(0, 0, size, size)
+}
+
+func (s *sliderImage) getKnobRadius() int {
+	return s.sliderOption.PieceSize / 5
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 拼图形状遮罩：方形主体，上方和右侧凸起，左侧凹口，4x4超采样抗锯齿
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *sliderImage) getPieceMask() *image.Alpha {
+	bounds := s.getPieceBounds()
+	mask := image.NewAlpha(bounds)
+
+	size := float64(s.sliderOption.PieceSize)
+	radius := float64(s.getKnobRadius())
+	knob := radius * 0.85
+
+	//主体区域
+	left, top := 1.0, 1.0+radius
+	right, bottom := left+size, top+size
+
+	inCircle := func(x, y, centerX, centerY, radius float64) bool {
+		return (x-centerX)*(x-centerX)+(y-centerY)*(y-centerY) <= radius*radius
+	}
+
+	isInside := func(x, y float64) bool {
+		//左侧凹口
+		if inCircle(x, y, left, top+size/2, knob) {
+			return false
+		}
+
+		if x >= left && x <= right && y >= top && y <= bottom {
+			return true
+		}
+
+		//上方和右侧凸起
+		return inCircle(x, y, left+size/2, top, knob) || inCircle(x, y, right, top+size/2, knob)
+	}
+
+	const samples = 4
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			count := 0
+			for sy := 0; sy < samples; sy++ {
+				for sx := 0; sx < samples; sx++ {
+					if isInside(float64(x)+(float64(sx)+0.5)/samples, float64(y)+(float64(sy)+0.5)/samples) {
+						count++
+					}
+				}
+			}
+
+			mask.SetAlpha(x, y, color.Alpha{uint8(count * 255 / (samples * samples))})
+		}
+	}
+
+	return mask
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 无背景图时绘制随机双色渐变
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *sliderImage) drawGradient(dst *image.RGBA) {
+	random := s.random()
+
+	var from, to [3]float64
+	for index := 0; index < 3; index++ {
+		from[index] = randFloatRange(random, 40, 220)
+		to[index] = randFloatRange(random, 40, 220)
+	}
+
+	bounds := dst.Bounds()
+	angle := random.Float64() * 2 * math.Pi
+	sin, cos := math.Sincos(angle)
+	length := math.Abs(float64(bounds.Dx())*cos) + math.Abs(float64(bounds.Dy())*sin)
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			dx := float64(x-bounds.Min.X) - float64(bounds.Dx())/2
+			dy := float64(y-bounds.Min.Y) - float64(bounds.Dy())/2
+			ratio := math.Max(0, math.Min(1, (dx*cos+dy*sin)/length+0.5))
+
+			offset := dst.PixOffset(x, y)
+			for index := 0; index < 3; index++ {
+				dst.Pix[offset+index] = uint8(from[index] + (to[index]-from[index])*ratio)
+			}
+			dst.Pix[offset+3] = 255
+		}
+	}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取随机数源
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *sliderImage) random() IRandom {
+	return getRandom(s.option.Random)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取答案选择随机数源
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *sliderImage) answerRandom() IRandom {
+	return getAnswerRandom(s.option.Random, s.option.IsSecure)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 遮罩边缘，像素值与四邻域最小值之差
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func getMaskEdge(mask *image.Alpha) *image.Alpha {
+	bounds := mask.Bounds()
+	edge := image.NewAlpha(bounds)
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			value := mask.AlphaAt(x, y).A
+			min := value
+			for _, point := range []image.Point{{x - 1, y}, {x + 1, y}, {x, y - 1}, {x, y + 1}} {
+				//画布外视为透明
+				neighbour := mask.AlphaAt(point.X, point.Y).A
+				if neighbour < min {
+					min = neighbour
+				}
+			}
+
+			edge.SetAlpha(x, y, color.Alpha{value - min})
+		}
+	}
+
+	return edge
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 判断拖动轨迹是否像人工操作：点数足够、时间递增、耗时不过短，
+ * 终点与提交的x相差不超过tolerance，且不是匀速直线（x按时间线性增长并且y没有抖动）
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func IsHumanTrack(track []SlidePoint, x, tolerance float64) bool {
+	if len(track) < slideTrackMinPoints {
+		return false
+	}
+
+	first, last := track[0], track[len(track)-1]
+	if math.Abs(last.X-x) > tolerance {
+		return false
+	}
+
+	duration := last.T - first.T
+	if duration < slideTrackMinDuration {
+		return false
+	}
+
+	for index := 1; index < len(track); index++ {
+		if track[index].T < track[index-1].T {
+			return false
+		}
+	}
+
+	//与首尾两点间匀速直线的最大偏差
+	var maxOffset float64
+	for _, point := range track {
+		ratio := float64(point.T-first.T) / float64(duration)
+		expectX := first.X + (last.X-first.X)*ratio
+		expectY := first.Y + (last.Y-first.Y)*ratio
+
+		maxOffset = math.Max(maxOffset, math.Max(math.Abs(point.X-expectX), math.Abs(point.Y-expectY)))
+	}
+
+	return maxOffset >= slideTrackMinOffset
+}
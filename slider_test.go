@@ -0,0 +1,75 @@
+package gcaptcha
+
+import (
+	"strconv"
+	"testing"
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 生成从0拖动到x的轨迹，中途带有加减速和y方向抖动
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func humanTrack(x float64) []SlidePoint {
+	ratios := []float64{0, 0.05, 0.2, 0.45, 0.7, 0.88, 0.97, 1}
+	track := make([]SlidePoint, 0, len(ratios))
+	for index, ratio := range ratios {
+		track = append(track, SlidePoint{
+			X: x * ratio,
+			Y: float64(index % 3),
+			T: int64(1000 + index*60),
+		})
+	}
+
+	return track
+}
+
+func TestIsHumanTrack(t *testing.T) {
+	linear := make([]SlidePoint, 0, 8)
+	for index := 0; index < 8; index++ {
+		linear = append(linear, SlidePoint{X: float64(index * 20), T: int64(index * 60)})
+	}
+
+	tests := []struct {
+		name  string
+		track []SlidePoint
+		x     float64
+		want  bool
+	}{
+		{"human", humanTrack(140), 140, true},
+		{"end within tolerance", humanTrack(143), 140, true},
+		{"end far from x", humanTrack(80), 140, false},
+		{"too few points", []SlidePoint{{0, 0, 0}, {60, 2, 150}, {125, 1, 300}, {140, 0, 450}}, 140, false},
+		{"too fast", []SlidePoint{{0, 0, 0}, {30, 1, 10}, {90, 0, 20}, {130, 2, 30}, {140, 0, 40}}, 140, false},
+		{"time goes back", append(humanTrack(140)[:4], SlidePoint{X: 140, T: 0}), 140, false},
+		{"uniform straight line", linear, 140, false},
+	}
+
+	for _, test := range tests {
+		if got := IsHumanTrack(test.track, test.x, 4); got != test.want {
+			t.Errorf("%s: IsHumanTrack = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestSliderRequireTrack(t *testing.T) {
+	for _, requireTrack := range []bool{false, true} {
+		img := NewSliderImage("", SliderOption{RequireTrack: requireTrack})
+		img.SetOption(ImageOption{Random: NewRandom(1)})
+
+		x, err := strconv.ParseFloat(img.GetText()[0], 64)
+		if err != nil {
+			t.Fatalf("GetText: %v", err)
+		}
+
+		if got := img.VerifySlide(x, nil); got == requireTrack {
+			t.Errorf("RequireTrack %v: VerifySlide without track = %v", requireTrack, got)
+		}
+
+		if !img.VerifySlide(x, humanTrack(x)) {
+			t.Errorf("RequireTrack %v: VerifySlide with human track failed", requireTrack)
+		}
+
+		if img.VerifySlide(x, humanTrack(x/2)) {
+			t.Errorf("RequireTrack %v: VerifySlide accepted a track ending away from x", requireTrack)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+package gcaptcha
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+/* ================================================================================
+ * 语音素材库
+ * qq group: 582452342
+ * email   : 2091938785@qq.com
+ * author  : 美丽的地球啊 - mliu
+ * ================================================================================ */
+var (
+	ErrWavFormat = errors.New("sound bank: unsupported wav format, need 8 or 16 bit pcm")
+)
+
+type (
+	//每个字符对应一段单声道采样，取值范围 [-1, 1]
+	ISoundBank interface {
+		GetSound(text string, sampleRate int) ([]float64, error)
+		GetTexts() []string
+	}
+
+	fsSoundBank struct {
+		fsys   fs.FS
+		root   string
+		sounds map[string]*pcmSound
+		mutex  sync.RWMutex
+	}
+
+	digitSoundBank struct{}
+
+	pcmSound struct {
+		samples    []float64
+		sampleRate int
+	}
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 初始化fs.FS素材库（如embed.FS），读取 root/字符.wav，支持8位或16位PCM，
+ * 多声道混合为单声道
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func NewFSSoundBank(fsys fs.FS, root string) ISoundBank {
+	return &fsSoundBank{
+		fsys:   fsys,
+		root:   root,
+		sounds: make(map[string]*pcmSound, 0),
+	}
+}
+
+func (s *fsSoundBank) GetSound(text string, sampleRate int) ([]float64, error) {
+	s.mutex.RLock()
+	sound, ok := s.sounds[text]
+	s.mutex.RUnlock()
+
+	if !ok {
+		data, err := fs.ReadFile(s.fsys, path.Join(s.root, text+".wav"))
+		if err != nil {
+			return nil, fmt.Errorf("sound bank: no sound for %q: %v", text, err)
+		}
+
+		sound, err = readWav(data)
+		if err != nil {
+			return nil, err
+		}
+
+		s.mutex.Lock()
+		s.sounds[text] = sound
+		s.mutex.Unlock()
+	}
+
+	return resampleSound(sound.samples, float64(sound.sampleRate)/float64(sampleRate)), nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取素材库中全部字符，用于生成背景人声
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *fsSoundBank) GetTexts() []string {
+	entries, err := fs.ReadDir(s.fsys, s.root)
+	if err != nil {
+		return nil
+	}
+
+	texts := make([]string, 0)
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".wav") {
+			texts = append(texts, strings.TrimSuffix(entry.Name(), ".wav"))
+		}
+	}
+	sort.Strings(texts)
+
+	return texts
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 内置数字素材库，无需额外文件：共振峰合成的普通话数字（零到九，带声调），
+ * 音质接近早期语音合成器，真人语音需要通过NewFSSoundBank提供录音
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func NewDigitSoundBank() ISoundBank {
+	return digitSoundBank{}
+}
+
+func (s digitSoundBank) GetSound(text string, sampleRate int) ([]float64, error) {
+	if len(text) != 1 || text[0] < '0' || text[0] > '9' {
+		return nil, fmt.Errorf("sound bank: no sound for %q", text)
+	}
+
+	return synthSpeechDigit(int(text[0]-'0'), sampleRate), nil
+}
+
+func (s digitSoundBank) GetTexts() []string {
+	return []string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9"}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 线性插值重采样，ratio大于1时变短（音调升高）
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func resampleSound(samples []float64, ratio float64) []float64 {
+	if ratio <= 0 || len(samples) == 0 {
+		return nil
+	}
+
+	count := int(float64(len(samples)) / ratio)
+	results := make([]float64, count)
+	for index := range results {
+		position := float64(index) * ratio
+		left := int(position)
+		right := left + 1
+		if right >= len(samples) {
+			right = len(samples) - 1
+		}
+
+		fraction := position - float64(left)
+		results[index] = samples[left]*(1-fraction) + samples[right]*fraction
+	}
+
+	return results
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 解析RIFF WAVE数据
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func readWav(data []byte) (*pcmSound, error) {
+	reader := bytes.NewReader(data)
+
+	var header struct {
+		Riff   [4]byte
+		Size   uint32
+		Format [4]byte
+	}
+	if err := binary.Read(reader, binary.LittleEndian, &header); err != nil {
+		return nil, err
+	}
+
+	if string(header.Riff[:]) != "RIFF" || string(header.Format[:]) != "WAVE" {
+		return nil, ErrWavFormat
+	}
+
+	var format struct {
+		AudioFormat   uint16
+		Channels      uint16
+		SampleRate    uint32
+		ByteRate      uint32
+		BlockAlign    uint16
+		BitsPerSample uint16
+	}
+	var pcmData []byte
+
+	for {
+		var chunk struct {
+			Id   [4]byte
+			Size uint32
+		}
+		if err := binary.Read(reader, binary.LittleEndian, &chunk); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+
+		//容忍数据块被截断的文件
+		size := int64(chunk.Size)
+		if size > int64(reader.Len()) {
+			if string(chunk.Id[:]) != "data" {
+				return nil, io.ErrUnexpectedEOF
+			}
+			size = int64(reader.Len())
+		}
+
+		body := make([]byte, size)
+		if _, err := io.ReadFull(reader, body); err != nil {
+			return nil, err
+		}
+
+		//块按偶数字节对齐
+		if chunk.Size%2 == 1 {
+			reader.ReadByte()
+		}
+
+		switch string(chunk.Id[:]) {
+		case "fmt ":
+			if err := binary.Read(bytes.NewReader(body), binary.LittleEndian, &format); err != nil {
+				return nil, err
+			}
+		case "data":
+			pcmData = body
+		}
+	}
+
+	if format.AudioFormat != 1 || format.Channels == 0 || format.SampleRate == 0 ||
+		(format.BitsPerSample != 8 && format.BitsPerSample != 16) {
+		return nil, ErrWavFormat
+	}
+
+	channels := int(format.Channels)
+	sampleSize := int(format.BitsPerSample) / 8
+	frameCount := len(pcmData) / (channels * sampleSize)
+
+	samples := make([]float64, frameCount)
+	for index := range samples {
+		var value float64
+		for channel := 0; channel < channels; channel++ {
+			offset := (index*channels + channel) * sampleSize
+			if sampleSize == 1 {
+				value += (float64(pcmData[offset]) - 128) / 128
+			} else {
+				value += float64(int16(binary.LittleEndian.Uint16(pcmData[offset:]))) / 32768
+			}
+		}
+		samples[index] = value / float64(channels)
+	}
+
+	return &pcmSound{
+		samples:    samples,
+		sampleRate: int(format.SampleRate),
+	}, nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 编码为16位单声道PCM WAVE数据，超出 [-1, 1] 的采样被截断
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func writeWav(samples []float64, sampleRate int) ([]byte, error) {
+	var buffer bytes.Buffer
+
+	dataSize := uint32(len(samples) * 2)
+	header := struct {
+		Riff          [4]byte
+		Size          uint32
+		Wave          [4]byte
+		Fmt           [4]byte
+		FmtSize       uint32
+		AudioFormat   uint16
+		Channels      uint16
+		SampleRate    uint32
+		ByteRate      uint32
+		BlockAlign    uint16
+		BitsPerSample uint16
+		Data          [4]byte
+		DataSize      uint32
+	}{
+		Riff:          [4]byte{'R', 'I', 'F', 'F'},
+		Size:          36 + dataSize,
+		Wave:          [4]byte{'W', 'A', 'V', 'E'},
+		Fmt:           [4]byte{'f', 'm', 't', ' '},
+		FmtSize:       16,
+		AudioFormat:   1,
+		Channels:      1,
+		SampleRate:    uint32(sampleRate),
+		ByteRate:      uint32(sampleRate * 2),
+		BlockAlign:    2,
+		BitsPerSample: 16,
+		Data:          [4]byte{'d', 'a', 't', 'a'},
+		DataSize:      dataSize,
+	}
+
+	if err := binary.Write(&buffer, binary.LittleEndian, &header); err != nil {
+		return nil, err
+	}
+
+	pcmData := make([]int16, len(samples))
+	for index, sample := range samples {
+		pcmData[index] = int16(math.Max(-1, math.Min(1, sample)) * 32767)
+	}
+
+	if err := binary.Write(&buffer, binary.LittleEndian, pcmData); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,142 @@
+package gcaptcha
+
+import (
+	"math"
+	"strconv"
+	"testing"
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 归一化互相关，长度不同时按较短的计算
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func soundCorrelation(a, b []float64) float64 {
+	count := len(a)
+	if len(b) < count {
+		count = len(b)
+	}
+
+	var dot, normA, normB float64
+	for index := 0; index < count; index++ {
+		dot += a[index] * b[index]
+		normA += a[index] * a[index]
+		normB += b[index] * b[index]
+	}
+
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+
+	return dot / math.Sqrt(normA*normB)
+}
+
+func TestWavRoundTrip(t *testing.T) {
+	tests := []struct {
+		name       string
+		samples    []float64
+		sampleRate int
+	}{
+		{"empty", []float64{}, 8000},
+		{"ramp", []float64{-1, -0.5, 0, 0.25, 0.5, 0.999}, 16000},
+		{"digit", synthSpeechDigit(7, 22050), 22050},
+	}
+
+	for _, test := range tests {
+		data, err := writeWav(test.samples, test.sampleRate)
+		if err != nil {
+			t.Fatalf("%s: writeWav: %v", test.name, err)
+		}
+
+		sound, err := readWav(data)
+		if err != nil {
+			t.Fatalf("%s: readWav: %v", test.name, err)
+		}
+
+		if sound.sampleRate != test.sampleRate {
+			t.Errorf("%s: sample rate = %d, want %d", test.name, sound.sampleRate, test.sampleRate)
+		}
+
+		if len(sound.samples) != len(test.samples) {
+			t.Fatalf("%s: %d samples, want %d", test.name, len(sound.samples), len(test.samples))
+		}
+
+		//16位量化误差
+		for index, sample := range test.samples {
+			if math.Abs(sound.samples[index]-sample) > 2.0/32768 {
+				t.Fatalf("%s: sample %d = %v, want %v", test.name, index, sound.samples[index], sample)
+			}
+		}
+	}
+}
+
+func TestReadWavRejectsUnsupported(t *testing.T) {
+	data, err := writeWav([]float64{0, 0.5}, 8000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//AudioFormat改为3（浮点）
+	data[20] = 3
+	if _, err := readWav(data); err != ErrWavFormat {
+		t.Fatalf("readWav float format err = %v, want ErrWavFormat", err)
+	}
+
+	if _, err := readWav([]byte("RIFF")); err == nil {
+		t.Fatal("readWav accepted a truncated header")
+	}
+}
+
+func TestDigitSoundBankDistinct(t *testing.T) {
+	bank := NewDigitSoundBank()
+
+	sounds := make([][]float64, 0, 10)
+	for _, text := range bank.GetTexts() {
+		sound, err := bank.GetSound(text, 16000)
+		if err != nil {
+			t.Fatalf("GetSound(%q): %v", text, err)
+		}
+
+		if len(sound) < 16000/5 {
+			t.Fatalf("GetSound(%q): %d samples, too short", text, len(sound))
+		}
+
+		for _, sample := range sound {
+			if math.IsNaN(sample) || math.Abs(sample) > 1 {
+				t.Fatalf("GetSound(%q): sample %v out of range", text, sample)
+			}
+		}
+
+		sounds = append(sounds, sound)
+	}
+
+	for i := range sounds {
+		for j := i + 1; j < len(sounds); j++ {
+			if value := soundCorrelation(sounds[i], sounds[j]); value > 0.9 {
+				t.Errorf("digits %d and %d correlate %.2f", i, j, value)
+			}
+		}
+	}
+
+	if _, err := bank.GetSound("a", 16000); err == nil {
+		t.Error("GetSound(\"a\") succeeded")
+	}
+}
+
+func TestBabbleSoundUnlikeSource(t *testing.T) {
+	audio := NewAudio(AudioOption{Random: NewRandom(1)})
+
+	for digit := 0; digit < 10; digit++ {
+		sound, err := audio.option.Bank.GetSound(strconv.Itoa(digit), audio.option.SampleRate)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		babble := audio.getBabbleSound(sound, NewRandom(int64(digit)))
+		if len(babble) != len(sound) {
+			t.Fatalf("digit %d: babble has %d samples, want %d", digit, len(babble), len(sound))
+		}
+
+		if value := soundCorrelation(sound, babble); math.Abs(value) > 0.5 {
+			t.Errorf("digit %d: babble correlates %.2f with the source", digit, value)
+		}
+	}
+}
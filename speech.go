@@ -0,0 +1,274 @@
+package gcaptcha
+
+import (
+	"math"
+)
+
+/* ================================================================================
+ * 共振峰语音合成，用于内置普通话数字素材
+ * qq group: 582452342
+ * email   : 2091938785@qq.com
+ * author  : 美丽的地球啊 - mliu
+ * ================================================================================ */
+const (
+	speechTone1 = iota + 1 //阴平
+	speechTone2            //阳平
+	speechTone3            //上声
+	speechTone4            //去声
+)
+
+type (
+	//音段，浊音经过三个共振峰串联滤波，噪声经过单个共振峰滤波
+	speechSegment struct {
+		duration       float64    //时长（秒）
+		formants       [3]float64 //共振峰频率
+		voice          float64    //浊音强度
+		noise          float64    //噪声强度
+		noiseFrequency float64    //噪声中心频率
+	}
+
+	speechSyllable struct {
+		tone     int
+		segments []speechSegment
+	}
+
+	//二阶谐振器
+	speechResonator struct {
+		a, b, c float64
+		y1, y2  float64
+	}
+)
+
+var (
+	speechVowelI  = [3]float64{280, 2250, 2900}
+	speechVowelA  = [3]float64{800, 1250, 2600}
+	speechVowelU  = [3]float64{320, 750, 2300}
+	speechVowelO  = [3]float64{500, 900, 2500}
+	speechVowelE  = [3]float64{550, 1500, 2500}
+	speechVowelEr = [3]float64{500, 1400, 1700}
+	speechVowelZi = [3]float64{380, 1400, 2700}
+	speechNasalN  = [3]float64{300, 1500, 2500}
+	speechNasalNg = [3]float64{300, 1000, 2300}
+	speechLiquidL = [3]float64{350, 1100, 2700}
+
+	//零 一 二 三 四 五 六 七 八 九
+	speechDigits = [10]speechSyllable{
+		{speechTone2, []speechSegment{
+			{0.07, speechLiquidL, 0.5, 0, 0},
+			{0.22, speechVowelI, 1, 0, 0},
+			{0.14, speechNasalNg, 0.4, 0, 0},
+		}},
+		{speechTone1, []speechSegment{
+			{0.38, speechVowelI, 1, 0, 0},
+		}},
+		{speechTone4, []speechSegment{
+			{0.14, speechVowelE, 1, 0, 0},
+			{0.22, speechVowelEr, 0.9, 0, 0},
+		}},
+		{speechTone1, []speechSegment{
+			{0.13, speechVowelZi, 0, 0.7, 5000},
+			{0.24, speechVowelA, 1, 0, 0},
+			{0.1, speechNasalN, 0.4, 0, 0},
+		}},
+		{speechTone4, []speechSegment{
+			{0.14, speechVowelZi, 0, 0.7, 5000},
+			{0.24, speechVowelZi, 1, 0, 0},
+		}},
+		{speechTone3, []speechSegment{
+			{0.42, speechVowelU, 1, 0, 0},
+		}},
+		{speechTone4, []speechSegment{
+			{0.07, speechLiquidL, 0.5, 0, 0},
+			{0.08, speechVowelI, 1, 0, 0},
+			{0.12, speechVowelO, 1, 0, 0},
+			{0.14, speechVowelU, 0.9, 0, 0},
+		}},
+		{speechTone1, []speechSegment{
+			{0.04, speechVowelI, 0, 0.9, 3500},
+			{0.09, speechVowelI, 0, 0.5, 3200},
+			{0.26, speechVowelI, 1, 0, 0},
+		}},
+		{speechTone1, []speechSegment{
+			{0.015, speechVowelA, 0, 0.8, 1200},
+			{0.33, speechVowelA, 1, 0, 0},
+		}},
+		{speechTone3, []speechSegment{
+			{0.05, speechVowelI, 0, 0.8, 3500},
+			{0.1, speechVowelI, 1, 0, 0},
+			{0.12, speechVowelO, 1, 0, 0},
+			{0.16, speechVowelU, 0.9, 0, 0},
+		}},
+	}
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 合成普通话数字，digit不在0-9时返回nil
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func synthSpeechDigit(digit int, sampleRate int) []float64 {
+	if digit < 0 || digit > 9 {
+		return nil
+	}
+
+	return synthSpeech(speechDigits[digit], sampleRate)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 合成单个音节：脉冲串声源按声调变化基频，音段之间的共振峰和强度平滑过渡
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func synthSpeech(syllable speechSyllable, sampleRate int) []float64 {
+	var duration float64
+	for _, segment := range syllable.segments {
+		duration += segment.duration
+	}
+
+	rate := float64(sampleRate)
+	count := int(duration * rate)
+	voices := make([]float64, count)
+	noises := make([]float64, count)
+
+	resonators := make([]speechResonator, 3)
+	noiseResonator := speechResonator{}
+
+	//固定种子的噪声源，保证素材稳定
+	noiseState := uint32(2463534242)
+
+	var phase, glottal float64
+	for index := range voices {
+		t := float64(index) / rate
+		segment := getSpeechSegment(syllable.segments, t)
+
+		//声源：每个周期一个脉冲，经一阶低通得到近似声门波
+		phase += getSpeechPitch(syllable.tone, t/duration) / rate
+		var pulse float64
+		if phase >= 1 {
+			phase -= 1
+			pulse = 1
+		}
+		glottal = glottal*0.9 + pulse
+
+		value := glottal * segment.voice
+		for formant := range resonators {
+			resonators[formant].set(segment.formants[formant], 60+float64(formant)*40, rate)
+			value = resonators[formant].filter(value)
+		}
+		voices[index] = value
+
+		noiseState ^= noiseState << 13
+		noiseState ^= noiseState >> 17
+		noiseState ^= noiseState << 5
+		noise := float64(noiseState)/float64(math.MaxUint32)*2 - 1
+
+		if segment.noise > 0 {
+			noiseResonator.set(segment.noiseFrequency, segment.noiseFrequency*0.3, rate)
+			noises[index] = noiseResonator.filter(noise) * segment.noise
+		}
+	}
+
+	//两路增益差异很大，分别归一化后按擦音比浊音低约9dB混合
+	scaleSpeechPeak(voices, 1)
+	scaleSpeechPeak(noises, 0.35)
+
+	samples := make([]float64, count)
+	for index := range samples {
+		samples[index] = (voices[index] + noises[index]) * getSpeechEnvelope(float64(index)/rate, duration)
+	}
+
+	scaleSpeechPeak(samples, 0.8)
+
+	return samples
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 缩放到指定峰值，全为0时不变
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func scaleSpeechPeak(samples []float64, peak float64) {
+	var max float64
+	for _, sample := range samples {
+		max = math.Max(max, math.Abs(sample))
+	}
+
+	if max <= 0 {
+		return
+	}
+
+	for index := range samples {
+		samples[index] *= peak / max
+	}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取t秒处的音段参数，每个音段开头30ms内从上一音段线性过渡
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func getSpeechSegment(segments []speechSegment, t float64) speechSegment {
+	var start float64
+	for index, segment := range segments {
+		if t < start+segment.duration || index == len(segments)-1 {
+			transition := math.Min(0.03, segment.duration/2)
+			if index == 0 || t-start >= transition {
+				return segment
+			}
+
+			ratio := (t - start) / transition
+			previous := segments[index-1]
+			mix := func(from, to float64) float64 {
+				return from + (to-from)*ratio
+			}
+
+			result := speechSegment{
+				voice:          mix(previous.voice, segment.voice),
+				noise:          mix(previous.noise, segment.noise),
+				noiseFrequency: segment.noiseFrequency,
+			}
+			for formant := range result.formants {
+				result.formants[formant] = mix(previous.formants[formant], segment.formants[formant])
+			}
+
+			return result
+		}
+
+		start += segment.duration
+	}
+
+	return speechSegment{}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 声调基频，position为音节内的相对位置 [0, 1]
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func getSpeechPitch(tone int, position float64) float64 {
+	switch tone {
+	case speechTone2:
+		return 110 + 45*position*position
+	case speechTone3:
+		return 105 - 25*math.Sin(math.Pi*math.Min(position*1.4, 1))
+	case speechTone4:
+		return 165 - 75*position
+	}
+
+	return 150
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 音节包络，淡入10ms，淡出60ms
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func getSpeechEnvelope(t, duration float64) float64 {
+	return math.Min(1, math.Min(t/0.01, (duration-t)/0.06))
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 设置谐振器的中心频率和带宽，中心频率不超过奈奎斯特频率的90%
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *speechResonator) set(frequency, bandwidth, sampleRate float64) {
+	frequency = math.Min(frequency, sampleRate*0.45)
+
+	s.c = -math.Exp(-2 * math.Pi * bandwidth / sampleRate)
+	s.b = 2 * math.Exp(-math.Pi*bandwidth/sampleRate) * math.Cos(2*math.Pi*frequency/sampleRate)
+	s.a = 1 - s.b - s.c
+}
+
+func (s *speechResonator) filter(value float64) float64 {
+	result := s.a*value + s.b*s.y1 + s.c*s.y2
+	s.y2, s.y1 = s.y1, result
+
+	return result
+}
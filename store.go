@@ -0,0 +1,170 @@
+package gcaptcha
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+/* ================================================================================
+ * 验证码存储
+ * qq group: 582452342
+ * email   : 2091938785@qq.com
+ * author  : 美丽的地球啊 - mliu
+ * ================================================================================ */
+var (
+	ErrStoreNotFound = errors.New("captcha store: key not found")
+)
+
+type (
+	IStore interface {
+		Set(key, value string, expire time.Duration) error
+		Get(key string, isClear bool) (string, error)
+		Delete(key string) error
+	}
+
+	memoryStore struct {
+		items  map[string]*memoryItem
+		mutex  sync.Mutex
+		ticker *time.Ticker
+		done   chan struct{}
+		once   sync.Once
+	}
+
+	memoryItem struct {
+		value    string
+		expireAt time.Time
+	}
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 初始化内存存储，interval为过期清理周期
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func NewMemoryStore(interval time.Duration) *memoryStore {
+	memoryStore := &memoryStore{
+		items: make(map[string]*memoryItem, 0),
+		done:  make(chan struct{}),
+	}
+
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	memoryStore.ticker = time.NewTicker(interval)
+	go memoryStore.sweep()
+
+	return memoryStore
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 写入数据，expire小于等于0表示永不过期
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *memoryStore) Set(key, value string, expire time.Duration) error {
+	item := &memoryItem{
+		value: value,
+	}
+
+	if expire > 0 {
+		item.expireAt = time.Now().Add(expire)
+	}
+
+	s.mutex.Lock()
+	s.items[key] = item
+	s.mutex.Unlock()
+
+	return nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 读取数据，isClear为true时读取后立即删除
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *memoryStore) Get(key string, isClear bool) (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	item, ok := s.items[key]
+	if !ok {
+		return "", ErrStoreNotFound
+	}
+
+	if isClear {
+		delete(s.items, key)
+	}
+
+	if item.isExpired(time.Now()) {
+		delete(s.items, key)
+		return "", ErrStoreNotFound
+	}
+
+	return item.value, nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 删除数据
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *memoryStore) Delete(key string) error {
+	s.mutex.Lock()
+	delete(s.items, key)
+	s.mutex.Unlock()
+
+	return nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 键不存在或已过期时写入数据，返回是否写入成功
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *memoryStore) add(key, value string, expire time.Duration) bool {
+	now := time.Now()
+	item := &memoryItem{
+		value: value,
+	}
+
+	if expire > 0 {
+		item.expireAt = now.Add(expire)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if oldItem, ok := s.items[key]; ok && !oldItem.isExpired(now) {
+		return false
+	}
+
+	s.items[key] = item
+
+	return true
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 停止过期清理
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *memoryStore) Close() {
+	s.once.Do(func() {
+		s.ticker.Stop()
+		close(s.done)
+	})
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 定时清理过期数据
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *memoryStore) sweep() {
+	for {
+		select {
+		case now := <-s.ticker.C:
+			s.mutex.Lock()
+			for key, item := range s.items {
+				if item.isExpired(now) {
+					delete(s.items, key)
+				}
+			}
+			s.mutex.Unlock()
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (s *memoryItem) isExpired(now time.Time) bool {
+	return !s.expireAt.IsZero() && now.After(s.expireAt)
+}
@@ -2,19 +2,19 @@ package gcaptcha
 
 import (
 	"bytes"
+	"fmt"
 	"image"
 	"image/color"
 	"image/draw"
-	"image/png"
-	"io/ioutil"
+	"image/gif"
 	"sort"
 	"strings"
 )
 
 import (
 	"github.com/golang/freetype"
-	"github.com/golang/freetype/truetype"
 	"github.com/sanxia/glib"
+	"golang.org/x/image/math/fixed"
 )
 
 /* ================================================================================
@@ -25,15 +25,17 @@ import (
  * ================================================================================ */
 type (
 	textImage struct {
-		title   string
-		texts   []string //外部数据源
-		option  ImageOption
-		itemMap map[int]string //数据映射
-		cellMap map[int]string //文字映射
-		colors  []*image.Uniform
-		width   int
-		height  int
-		count   int
+		title       string
+		texts       []string //外部数据源
+		option      ImageOption
+		itemMap     map[int]string //数据映射
+		cellMap     map[int]string //文字映射
+		colors      []*image.Uniform
+		width       int
+		height      int
+		count       int
+		isGenerated bool
+		generateErr error
 	}
 )
 
@@ -65,20 +67,190 @@ func NewTextImage(title string, texts []string, count int) IImage {
 	return textImage
 }
 
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取标题
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *textImage) GetTitle() string {
+	return s.title
+}
+
 func (s *textImage) SetOption(option ImageOption) {
 	s.option = option
 }
 
 /* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
- * 获取图片数据
+ * 获取图片数据，按Output选择的格式编码
  * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
 func (s *textImage) GetImage() ([]byte, error) {
+	if err := s.generate(); err != nil {
+		return nil, err
+	}
+
+	texts := s.GetText()
+	if s.option.Output.Format == ImageFormatGif {
+		return s.getGifImage(texts)
+	}
+
+	graphics, err := s.drawImage(texts)
+	if err != nil {
+		return nil, err
+	}
+
+	return encodeImage(graphics, s.option.Output)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取未编码的图片，gif格式时为不含动画效果的单帧
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *textImage) GetRawImage() (image.Image, error) {
+	if err := s.generate(); err != nil {
+		return nil, err
+	}
+
+	return s.drawImage(s.GetText())
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 绘制背景、标题和文字，画布宽度按count个字符计算
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *textImage) drawImage(texts []string) (*image.RGBA, error) {
+	graphics, offsetPoint, err := s.getBaseImage()
+	if err != nil {
+		return nil, err
+	}
+
+	//文字图
+	glyphImages, glyphs, err := s.getGlyphImages(texts)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, glyphImage := range glyphImages {
+		draw.Draw(graphics, glyphImage.Bounds().Add(offsetPoint), glyphImage, glyphImage.Bounds().Min, draw.Over)
+	}
+
+	//噪声
+	for index := range glyphs {
+		glyphs[index] = glyphs[index].Add(offsetPoint)
+	}
+	drawNoises(graphics, s.option.Noises, glyphs, s.random())
+
+	//滤镜
+	graphics = applyFilters(graphics, s.option.Filters, s.random())
+
+	return graphics, nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取多帧gif数据，相邻帧交替隐藏奇偶位置的字形并随机漂移，
+ * 任何单帧都不显示完整答案
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *textImage) getGifImage(texts []string) ([]byte, error) {
 	var imageBuffer bytes.Buffer
 
+	frameCount := s.option.Output.FrameCount
+	if frameCount < 2 {
+		frameCount = 4
+	}
+
+	frameDelay := s.option.Output.FrameDelay
+	if frameDelay <= 0 {
+		frameDelay = 50
+	}
+
+	baseImage, offsetPoint, err := s.getBaseImage()
+	if err != nil {
+		return nil, err
+	}
+
+	glyphImages, glyphs, err := s.getGlyphImages(texts)
+	if err != nil {
+		return nil, err
+	}
+
+	//噪声在各帧保持一致
+	for index := range glyphs {
+		glyphs[index] = glyphs[index].Add(offsetPoint)
+	}
+	noiseImage := image.NewRGBA(baseImage.Bounds())
+	drawNoises(noiseImage, s.option.Noises, glyphs, s.random())
+
+	palette := s.getGifPalette()
+	phase := s.random().Intn(2)
+
+	animation := &gif.GIF{}
+	for frameIndex := 0; frameIndex < frameCount; frameIndex++ {
+		frame := image.NewRGBA(baseImage.Bounds())
+		draw.Draw(frame, frame.Bounds(), baseImage, image.ZP, draw.Src)
+
+		for glyphIndex, glyphImage := range glyphImages {
+			//隐藏的字形完全不绘制，淡化的字形阈值化后仍可辨认
+			if (glyphIndex+frameIndex+phase)%2 != 0 {
+				continue
+			}
+
+			driftPoint := image.Point{randIntRange(s.random(), -1, 2), randIntRange(s.random(), -1, 2)}
+			rect := glyphImage.Bounds().Add(offsetPoint).Add(driftPoint)
+			draw.Draw(frame, rect, glyphImage, glyphImage.Bounds().Min, draw.Over)
+		}
+
+		draw.Draw(frame, frame.Bounds(), noiseImage, image.ZP, draw.Over)
+		frame = applyFilters(frame, s.option.Filters, s.random())
+
+		paletted := image.NewPaletted(frame.Bounds(), palette)
+		draw.Draw(paletted, paletted.Bounds(), frame, image.ZP, draw.Src)
+
+		animation.Image = append(animation.Image, paletted)
+		animation.Delay = append(animation.Delay, frameDelay)
+	}
+
+	if err := gif.EncodeAll(&imageBuffer, animation); err != nil {
+		return nil, err
+	}
+
+	return imageBuffer.Bytes(), nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * gif调色板：黑白灰阶，以及文字颜色与白色的混合色阶
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *textImage) getGifPalette() color.Palette {
+	palette := make(color.Palette, 0)
+
+	const grayLevels = 16
+	for index := 0; index < grayLevels; index++ {
+		value := uint8(index * 255 / (grayLevels - 1))
+		palette = append(palette, color.RGBA{value, value, value, 255})
+	}
+
+	const colorLevels = 12
+	for _, uniform := range s.colors {
+		r, g, b, _ := uniform.C.RGBA()
+		for index := 1; index <= colorLevels; index++ {
+			ratio := float64(index) / colorLevels
+			palette = append(palette, color.RGBA{
+				uint8(float64(r>>8)*ratio + 255*(1-ratio)),
+				uint8(float64(g>>8)*ratio + 255*(1-ratio)),
+				uint8(float64(b>>8)*ratio + 255*(1-ratio)),
+				255,
+			})
+		}
+
+		if len(palette) >= 256-colorLevels {
+			break
+		}
+	}
+
+	return palette
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 计算画布尺寸并绘制背景和标题，同时返回文字图的偏移点
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *textImage) getBaseImage() (*image.RGBA, image.Point, error) {
 	headerHeight := s.option.HeaderHeight
 	width := s.option.CellWidth
 	height := s.option.CellHeight
-	texts := s.shuffle()
 
 	s.width = s.count*(width+s.option.Gap) + s.option.Gap + ((s.count - 1) * s.option.Padding)
 	s.height = 1*(height+s.option.Gap) + s.option.Gap + headerHeight + (2 * s.option.Padding)
@@ -98,25 +270,20 @@ func (s *textImage) GetImage() ([]byte, error) {
 
 	//标题图
 	if len(s.title) > 0 {
-		if titleImage, err := s.getTitleImage(); err == nil {
-			draw.Draw(graphics, titleImage.Bounds().Add(offsetPoint), titleImage, image.ZP, draw.Over)
+		titleImage, err := s.getTitleImage()
+		if err != nil {
+			return nil, image.ZP, err
 		}
+		draw.Draw(graphics, titleImage.Bounds().Add(offsetPoint), titleImage, image.ZP, draw.Over)
 	}
 
-	//文字图
+	//文字图偏移点
 	offsetPoint = image.Point{s.option.Padding, offsetPoint.Y}
 	if len(s.title) > 0 {
 		offsetPoint = image.Point{s.option.Padding, offsetPoint.Y + headerHeight}
 	}
 
-	textImage, _ := s.getTextImage(texts)
-	draw.Draw(graphics, textImage.Bounds().Add(offsetPoint), textImage, image.ZP, draw.Over)
-
-	if err := png.Encode(&imageBuffer, graphics); err != nil {
-		return nil, err
-	}
-
-	return imageBuffer.Bytes(), nil
+	return graphics, offsetPoint, nil
 }
 
 /* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
@@ -126,7 +293,14 @@ func (s *textImage) getTitleImage() (image.Image, error) {
 	graphics := image.NewRGBA(image.Rect(0, 0, s.width, s.height))
 	draw.Draw(graphics, graphics.Bounds(), image.Transparent, image.ZP, draw.Src)
 
-	font, _ := s.getFont(s.option.FontPath)
+	font, err := GetFont(s.option.FontPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkFontGlyphs(font, s.title); err != nil {
+		return nil, err
+	}
 
 	ctx := freetype.NewContext()
 	ctx.SetDPI(72)
@@ -151,44 +325,55 @@ func (s *textImage) getTitleImage() (image.Image, error) {
 }
 
 /* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
- * 获取文字图
+ * 获取每个字形的文字图，同时返回每个字形的大致区域
  * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
-func (s *textImage) getTextImage(texts []string) (image.Image, error) {
-	graphics := image.NewRGBA(image.Rect(0, 0, s.width, s.height))
-	draw.Draw(graphics, graphics.Bounds(), image.Transparent, image.ZP, draw.Src)
+func (s *textImage) getGlyphImages(texts []string) ([]*image.RGBA, []image.Rectangle, error) {
+	fonts, err := loadFonts(s.option.Fonts, s.option.FontPath)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	font, _ := s.getFont(s.option.FontPath)
+	//每个字形先绘制到独立图层，变换后再叠加，相邻字形可以轻微重叠
+	layerSize := int(s.option.FontSize*3) + 8
+	layer := image.NewRGBA(image.Rect(0, 0, layerSize, layerSize))
+	layerPoint := freetype.Pt(layerSize/4, layerSize*2/3)
 
 	ctx := freetype.NewContext()
 	ctx.SetDPI(72)
-	ctx.SetFont(font)
-	ctx.SetClip(graphics.Bounds())
-	ctx.SetDst(graphics)
+	ctx.SetClip(layer.Bounds())
+	ctx.SetDst(layer)
 
 	textPoint := freetype.Pt(2, 5)
+	glyphImages := make([]*image.RGBA, 0)
+	glyphs := make([]image.Rectangle, 0)
 	flags := make(map[int]bool, 0)
 	var nextIndex int
 
 	newTexts := strings.Join(texts, "")
 	for _, text := range newTexts {
-		colorIndex := glib.RandInt(len(s.colors))
+		colorIndex := s.random().Intn(len(s.colors))
 		ctx.SetSrc(s.colors[colorIndex])
+		font, err := pickFont(s.random(), fonts, text)
+		if err != nil {
+			return nil, nil, err
+		}
+		ctx.SetFont(font)
 
-		fontSize := glib.RandIntRange(int(s.option.FontSize), int(s.option.FontSize)+2)
-		offsetX := 14 + glib.RandIntRange(-2, 2)
-		offsetY := glib.RandIntRange(12, 18)
+		fontSize := randIntRange(s.random(), int(s.option.FontSize), int(s.option.FontSize)+2)
+		offsetX := 14 + randIntRange(s.random(), -2, 2)
+		offsetY := randIntRange(s.random(), 12, 18)
 
 		if string(text) == "#" || string(text) == "b" {
-			fontSize = glib.RandIntRange(int(s.option.FontSize)-6, int(s.option.FontSize)-2)
+			fontSize = randIntRange(s.random(), int(s.option.FontSize)-6, int(s.option.FontSize)-2)
 			flags[nextIndex] = true
 		}
 
 		if flags[nextIndex] {
-			offsetY = glib.RandIntRange(8, 14)
+			offsetY = randIntRange(s.random(), 8, 14)
 		}
 
 		if nextIndex > 0 && flags[nextIndex-1] {
-			offsetX = 8 + glib.RandIntRange(-5, 0)
+			offsetX = 8 + randIntRange(s.random(), -5, 0)
 		}
 
 		ctx.SetFontSize(float64(fontSize))
@@ -196,38 +381,102 @@ func (s *textImage) getTextImage(texts []string) (image.Image, error) {
 		textPoint.X += ctx.PointToFixed(float64(offsetX))
 		textPoint.Y = ctx.PointToFixed(float64(offsetY))
 
-		if _, err := ctx.DrawString(string(text), textPoint); err != nil {
-			return nil, err
+		draw.Draw(layer, layer.Bounds(), image.Transparent, image.ZP, draw.Src)
+		if _, err := ctx.DrawString(string(text), layerPoint); err != nil {
+			return nil, nil, err
 		}
 
+		glyphImages = append(glyphImages, s.getGlyphImage(layer, layerPoint, textPoint, float64(fontSize)))
+
+		advance := font.HMetric(fixed.I(fontSize), font.Index(text)).AdvanceWidth
+		glyphs = append(glyphs, image.Rect(
+			textPoint.X.Round(), textPoint.Y.Round()-fontSize*7/10,
+			(textPoint.X+advance).Round(), textPoint.Y.Round(),
+		))
+
 		nextIndex++
 	}
 
-	return graphics, nil
+	return glyphImages, glyphs, nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 将字形图层的基线原点layerPoint对齐到textPoint绘制，配置了变换时以字形中心做仿射变换，
+ * 返回的图片只覆盖笔画所在区域，坐标与文字画布一致
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *textImage) getGlyphImage(layer *image.RGBA, layerPoint, textPoint fixed.Point26_6, fontSize float64) *image.RGBA {
+	canvas := image.Rect(0, 0, s.width, s.height)
+
+	inkBounds := alphaBounds(layer)
+
+	if s.option.Transform.IsZero() {
+		offsetPoint := image.Point{textPoint.X.Round() - layerPoint.X.Round(), textPoint.Y.Round() - layerPoint.Y.Round()}
+		rect := inkBounds.Add(offsetPoint).Intersect(canvas)
+
+		glyphImage := image.NewRGBA(rect)
+		draw.Draw(glyphImage, rect, layer, rect.Min.Sub(offsetPoint), draw.Over)
+
+		return glyphImage
+	}
+
+	//字形中心相对基线原点的偏移
+	centerX, centerY := fontSize*0.35, -fontSize*0.35
+
+	center := [2]float64{float64(layerPoint.X)/64 + centerX, float64(layerPoint.Y)/64 + centerY}
+	target := [2]float64{float64(textPoint.X)/64 + centerX, float64(textPoint.Y)/64 + centerY}
+	matrix := s.option.Transform.matrix(s.random())
+
+	//双线性插值会影响笔画外1像素内的采样点
+	rect := transformedBounds(inkBounds.Inset(-1), center, target, matrix).Intersect(canvas)
+
+	glyphImage := image.NewRGBA(rect)
+	drawTransformed(glyphImage, layer, center, target, matrix)
+
+	return glyphImage
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 随机选择文字，首次获取答案或图片时执行，之后的图片都使用相同的文字
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *textImage) generate() error {
+	if s.isGenerated {
+		return s.generateErr
+	}
+	s.isGenerated = true
+
+	if s.count <= 0 || s.count > len(s.texts) {
+		s.generateErr = fmt.Errorf("text image: count %d out of range 1-%d", s.count, len(s.texts))
+		return s.generateErr
+	}
+
+	s.shuffle()
+
+	return nil
 }
 
 /* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
  * 随机文字
  * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
-func (s *textImage) shuffle() []string {
+func (s *textImage) shuffle() {
 	//随机打散texts到cellMap
 	for index, text := range s.texts {
 		s.itemMap[index] = text
 	}
 
-	index := glib.RandIntRange(0, s.count)
+	//首个位置同样在全部文字中选取，避免偏向前count个
+	index := randIntRange(s.answerRandom(), 0, len(s.itemMap))
 	s.cellMap[index] = s.itemMap[index]
 
 	count := s.count
 
 	//第一个已提前写入，所以是大于1
 	for count > 1 {
-		index = glib.RandIntRange(0, len(s.itemMap))
+		index = randIntRange(s.answerRandom(), 0, len(s.itemMap))
 		for {
 			if _, ok := s.cellMap[index]; !ok {
 				break
 			} else {
-				index = glib.RandIntRange(0, len(s.itemMap))
+				index = randIntRange(s.answerRandom(), 0, len(s.itemMap))
 			}
 		}
 
@@ -235,47 +484,48 @@ func (s *textImage) shuffle() []string {
 
 		count--
 	}
-
-	return s.GetText()
 }
 
 /* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
  * 获取文字宽度
  * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
-func (s *textImage) getTextWidth(fontSize int) int {
-	font, _ := s.getFont(s.option.FontPath)
+func (s *textImage) getTextWidth(fontSize int) (int, error) {
+	font, err := GetFont(s.option.FontPath)
+	if err != nil {
+		return 0, err
+	}
+
 	ctx := freetype.NewContext()
 	ctx.SetDPI(72)
 	ctx.SetFontSize(float64(fontSize))
 	ctx.SetFont(font)
 	space := float64(fontSize)
 
-	return int(ctx.PointToFixed(space))
+	return int(ctx.PointToFixed(space)), nil
 }
 
 /* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
- * 获取字体
+ * 获取随机数源
  * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
-func (s *textImage) getFont(fontPath string) (*truetype.Font, error) {
-	absolutePath := glib.GetAbsolutePath(fontPath)
-
-	fontBytes, err := ioutil.ReadFile(absolutePath)
-	if err != nil {
-		return nil, err
-	}
-
-	font, err := freetype.ParseFont(fontBytes)
-	if err != nil {
-		return nil, err
-	}
+func (s *textImage) random() IRandom {
+	return getRandom(s.option.Random)
+}
 
-	return font, nil
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取答案选择随机数源
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *textImage) answerRandom() IRandom {
+	return getAnswerRandom(s.option.Random, s.option.IsSecure)
 }
 
 /* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
  * 获取文字
  * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
 func (s *textImage) GetText() []string {
+	if err := s.generate(); err != nil {
+		return nil
+	}
+
 	//维持字典索引有序
 	keys := make([]int, 0)
 	for keyIndex := range s.cellMap {
@@ -0,0 +1,148 @@
+package gcaptcha
+
+import (
+	"bytes"
+	"flag"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files in testdata")
+
+func TestTextImageAnswerStableAcrossRenders(t *testing.T) {
+	img := NewTextImage("", []string{"a", "b", "c", "d", "e", "f"}, 4).(*textImage)
+	img.SetOption(ImageOption{
+		CellWidth:  30,
+		CellHeight: 40,
+		Gap:        2,
+		Padding:    2,
+		FontSize:   20,
+		Random:     NewRandom(1),
+	})
+
+	if _, err := img.GetImage(); err != nil {
+		t.Fatalf("GetImage: %v", err)
+	}
+	answer := img.GetText()
+
+	if _, err := img.GetRawImage(); err != nil {
+		t.Fatalf("GetRawImage: %v", err)
+	}
+	if _, err := img.GetImage(); err != nil {
+		t.Fatalf("GetImage again: %v", err)
+	}
+
+	if len(answer) != 4 {
+		t.Fatalf("answer = %v, want 4 texts", answer)
+	}
+
+	if texts := img.GetText(); !reflect.DeepEqual(texts, answer) {
+		t.Fatalf("answer changed from %v to %v", answer, texts)
+	}
+}
+
+func TestTextImageCountOutOfRange(t *testing.T) {
+	img := NewTextImage("", []string{"a", "b"}, 3)
+
+	if _, err := img.GetImage(); err == nil {
+		t.Fatal("GetImage succeeded with count larger than texts")
+	}
+
+	if texts := img.GetText(); len(texts) != 0 {
+		t.Fatalf("GetText = %v, want empty", texts)
+	}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 固定种子的文字图片，go test -run TextImageGolden -update 重新生成testdata
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func newGoldenTextImage() *textImage {
+	img := NewTextImage("", []string{"a", "b", "c", "d", "e", "f", "g", "h"}, 4).(*textImage)
+	img.SetOption(ImageOption{
+		CellWidth:  30,
+		CellHeight: 40,
+		Gap:        2,
+		Padding:    2,
+		FontSize:   22,
+		Transform:  GlyphTransform{Rotation: 20, Shear: 0.2},
+		Noises: []INoise{
+			DotNoise{Density: 5},
+			CurveNoise{Count: 1},
+		},
+		Filters: []IFilter{WaveFilter{Strength: 2}},
+		Random:  NewRandom(42),
+	})
+
+	return img
+}
+
+func TestTextImageGolden(t *testing.T) {
+	img := newGoldenTextImage()
+	data, err := img.GetImage()
+	if err != nil {
+		t.Fatalf("GetImage: %v", err)
+	}
+
+	//相同种子的两次生成答案和图片完全一致
+	again := newGoldenTextImage()
+	againData, err := again.GetImage()
+	if err != nil {
+		t.Fatalf("GetImage again: %v", err)
+	}
+
+	if !reflect.DeepEqual(img.GetText(), again.GetText()) {
+		t.Fatalf("answer %v differs from %v with the same seed", again.GetText(), img.GetText())
+	}
+
+	if !bytes.Equal(data, againData) {
+		t.Fatal("image differs with the same seed")
+	}
+
+	goldenPath := filepath.Join("testdata", "text_seed42.png")
+	if *update {
+		if err := os.MkdirAll("testdata", 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ioutil.WriteFile(goldenPath, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	goldenData, err := ioutil.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+
+	got, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := png.Decode(bytes.NewReader(goldenData))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Bounds() != want.Bounds() {
+		t.Fatalf("bounds %v, want %v", got.Bounds(), want.Bounds())
+	}
+
+	differences := 0
+	for y := want.Bounds().Min.Y; y < want.Bounds().Max.Y; y++ {
+		for x := want.Bounds().Min.X; x < want.Bounds().Max.X; x++ {
+			if !reflect.DeepEqual(color.NRGBAModel.Convert(got.At(x, y)), color.NRGBAModel.Convert(want.At(x, y))) {
+				differences++
+			}
+		}
+	}
+
+	if differences > 0 {
+		t.Errorf("%d pixels differ from %s", differences, goldenPath)
+	}
+}
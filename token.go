@@ -0,0 +1,211 @@
+package gcaptcha
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+/* ================================================================================
+ * 无状态签名令牌验证码
+ * qq group: 582452342
+ * email   : 2091938785@qq.com
+ * author  : 美丽的地球啊 - mliu
+ * ================================================================================ */
+var (
+	ErrTokenSecretEmpty = errors.New("captcha token: secret is empty")
+	ErrTokenExpireEmpty = errors.New("captcha token: expire must be positive")
+	ErrTokenAnswerKind  = errors.New("captcha token: tolerance answers are not supported, use Captcha with a store")
+)
+
+type (
+	INonceStore interface {
+		Use(nonce string, expire time.Duration) bool
+	}
+
+	TokenCaptcha struct {
+		secret []byte
+		expire time.Duration
+		nonce  INonceStore
+	}
+
+	tokenPayload struct {
+		Nonce    string `json:"n"`
+		Salt     string `json:"s"`
+		Kind     string `json:"k"`
+		Hash     string `json:"h"`
+		ExpireAt int64  `json:"e"`
+	}
+
+	memoryNonceStore struct {
+		store *memoryStore
+	}
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 初始化令牌验证码，expire必须大于0，同时决定nonce记录的保留时间，
+ * nonce为空时不做防重放校验
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func NewTokenCaptcha(secret []byte, expire time.Duration, nonce INonceStore) *TokenCaptcha {
+	return &TokenCaptcha{
+		secret: secret,
+		expire: expire,
+		nonce:  nonce,
+	}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 生成验证码，返回令牌和图片数据
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *TokenCaptcha) Generate(img IImage) (string, []byte, error) {
+	if len(s.secret) == 0 {
+		return "", nil, ErrTokenSecretEmpty
+	}
+
+	//永不过期的令牌会让nonce集合无限增长
+	if s.expire <= 0 {
+		return "", nil, ErrTokenExpireEmpty
+	}
+
+	//随机源不可用时Guid返回空字符串，nonce无法防重放，盐也会失效
+	nonce, salt := newGuid(), newGuid()
+	if nonce == "" || salt == "" {
+		return "", nil, ErrCaptchaIdGenerate
+	}
+
+	imageData, err := img.GetImage()
+	if err != nil {
+		return "", nil, err
+	}
+
+	answer := newCaptchaAnswer(img)
+	if toleranceAnswerKinds[answer.Kind] {
+		return "", nil, ErrTokenAnswerKind
+	}
+
+	payload := &tokenPayload{
+		Nonce:    nonce,
+		Salt:     salt,
+		Kind:     answer.Kind,
+		ExpireAt: time.Now().Add(s.expire).Unix(),
+	}
+	payload.Hash = s.hashAnswer(payload.Salt, payload.Kind, answer.Texts)
+
+	payloadData, err := json.Marshal(payload)
+	if err != nil {
+		return "", nil, err
+	}
+
+	body := base64.RawURLEncoding.EncodeToString(payloadData)
+	token := body + "." + base64.RawURLEncoding.EncodeToString(s.sign(body))
+
+	return token, imageData, nil
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 校验令牌签名、过期时间、重放和答案
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *TokenCaptcha) VerifyToken(token string, answers []string) bool {
+	if len(s.secret) == 0 {
+		return false
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return false
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(signature, s.sign(parts[0])) {
+		return false
+	}
+
+	payloadData, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return false
+	}
+
+	var payload tokenPayload
+	if err := json.Unmarshal(payloadData, &payload); err != nil {
+		return false
+	}
+
+	//过期，没有过期时间的令牌一律拒绝
+	expire := time.Until(time.Unix(payload.ExpireAt, 0))
+	if payload.ExpireAt <= 0 || expire <= 0 {
+		return false
+	}
+
+	//重放，无论答案是否正确令牌都只能使用一次
+	if s.nonce != nil && !s.nonce.Use(payload.Nonce, expire) {
+		return false
+	}
+
+	if len(answers) == 0 {
+		return false
+	}
+
+	hash := s.hashAnswer(payload.Salt, payload.Kind, answers)
+
+	return hmac.Equal([]byte(hash), []byte(payload.Hash))
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 答案加盐哈希
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *TokenCaptcha) hashAnswer(salt, kind string, answers []string) string {
+	texts := normalizeAnswers(answers)
+	if kind == answerKindUnordered {
+		sort.Strings(texts)
+	}
+
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(salt))
+	mac.Write([]byte{0})
+	mac.Write([]byte(strings.Join(texts, "\x00")))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 令牌签名
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *TokenCaptcha) sign(body string) []byte {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte("token."))
+	mac.Write([]byte(body))
+
+	return mac.Sum(nil)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 初始化内存防重放集合，interval为过期清理周期
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func NewMemoryNonceStore(interval time.Duration) *memoryNonceStore {
+	return &memoryNonceStore{
+		store: NewMemoryStore(interval),
+	}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 标记nonce已使用，已使用过或expire不大于0时返回false，保证记录都会被清理
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *memoryNonceStore) Use(nonce string, expire time.Duration) bool {
+	if nonce == "" || expire <= 0 {
+		return false
+	}
+
+	return s.store.add(nonce, "", expire)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 停止过期清理
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s *memoryNonceStore) Close() {
+	s.store.Close()
+}
@@ -0,0 +1,160 @@
+package gcaptcha
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestTextImage(texts []string, count int) IImage {
+	img := NewTextImage("", texts, count)
+	img.SetOption(ImageOption{
+		CellWidth:  30,
+		CellHeight: 40,
+		FontSize:   20,
+		Random:     NewRandom(1),
+	})
+
+	return img
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 用captcha的密钥签名任意载荷，模拟过期或缺少过期时间的令牌
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func signTestToken(t *testing.T, captcha *TokenCaptcha, payload *tokenPayload) string {
+	payloadData, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := base64.RawURLEncoding.EncodeToString(payloadData)
+
+	return body + "." + base64.RawURLEncoding.EncodeToString(captcha.sign(body))
+}
+
+func TestTokenCaptchaGenerateErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		secret  []byte
+		expire  time.Duration
+		img     IImage
+		wantErr error
+	}{
+		{"empty secret", nil, time.Minute, newTestTextImage([]string{"a", "b"}, 2), ErrTokenSecretEmpty},
+		{"zero expire", []byte("secret"), 0, newTestTextImage([]string{"a", "b"}, 2), ErrTokenExpireEmpty},
+		{"negative expire", []byte("secret"), -time.Minute, newTestTextImage([]string{"a", "b"}, 2), ErrTokenExpireEmpty},
+		{"tolerance answer", []byte("secret"), time.Minute, NewSliderImage("", SliderOption{}), ErrTokenAnswerKind},
+	}
+
+	for _, test := range tests {
+		captcha := NewTokenCaptcha(test.secret, test.expire, nil)
+		if _, _, err := captcha.Generate(test.img); err != test.wantErr {
+			t.Errorf("%s: err = %v, want %v", test.name, err, test.wantErr)
+		}
+	}
+
+	//nonce或盐任一为空都不能签发令牌
+	guid := newGuid
+	defer func() { newGuid = guid }()
+
+	for emptyCall := 1; emptyCall <= 2; emptyCall++ {
+		call := 0
+		newGuid = func() string {
+			call++
+			if call == emptyCall {
+				return ""
+			}
+			return guid()
+		}
+
+		captcha := NewTokenCaptcha([]byte("secret"), time.Minute, nil)
+		if token, _, err := captcha.Generate(newTestTextImage([]string{"a", "b"}, 2)); err != ErrCaptchaIdGenerate || token != "" {
+			t.Errorf("empty guid on call %d: token %q, err = %v, want ErrCaptchaIdGenerate", emptyCall, token, err)
+		}
+	}
+}
+
+func TestTokenCaptchaVerify(t *testing.T) {
+	nonce := NewMemoryNonceStore(time.Minute)
+	defer nonce.Close()
+
+	captcha := NewTokenCaptcha([]byte("secret"), time.Minute, nonce)
+
+	img := newTestTextImage([]string{"a", "b", "c", "d"}, 3)
+	token, _, err := captcha.Generate(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	answers := img.GetText()
+
+	payloadData, err := base64.RawURLEncoding.DecodeString(strings.Split(token, ".")[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var payload tokenPayload
+	if err := json.Unmarshal(payloadData, &payload); err != nil {
+		t.Fatal(err)
+	}
+
+	expired := payload
+	expired.Nonce = "expired"
+	expired.ExpireAt = time.Now().Add(-time.Second).Unix()
+
+	forever := payload
+	forever.Nonce = "forever"
+	forever.ExpireAt = 0
+
+	wrongToken, _, err := captcha.Generate(newTestTextImage([]string{"a", "b", "c", "d"}, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		answers []string
+		want    bool
+	}{
+		{"correct", token, answers, true},
+		{"replay", token, answers, false},
+		{"wrong answer", wrongToken, []string{"x", "y", "z"}, false},
+		{"replay after wrong answer", wrongToken, answers, false},
+		{"expired", signTestToken(t, captcha, &expired), answers, false},
+		{"no expire", signTestToken(t, captcha, &forever), answers, false},
+		{"tampered", token[:len(token)-2] + "AA", answers, false},
+		{"malformed", "token", answers, false},
+	}
+
+	for _, test := range tests {
+		if got := captcha.VerifyToken(test.token, test.answers); got != test.want {
+			t.Errorf("%s: VerifyToken = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestMemoryNonceStoreUse(t *testing.T) {
+	nonce := NewMemoryNonceStore(time.Minute)
+	defer nonce.Close()
+
+	tests := []struct {
+		name   string
+		nonce  string
+		expire time.Duration
+		want   bool
+	}{
+		{"first use", "a", time.Minute, true},
+		{"second use", "a", time.Minute, false},
+		{"empty nonce", "", time.Minute, false},
+		{"no expire", "b", 0, false},
+		{"after no expire", "b", time.Minute, true},
+	}
+
+	for _, test := range tests {
+		if got := nonce.Use(test.nonce, test.expire); got != test.want {
+			t.Errorf("%s: Use = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package gcaptcha
+
+import (
+	"image"
+	"image/draw"
+	"math"
+)
+
+import (
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/math/f64"
+)
+
+/* ================================================================================
+ * 字形仿射变换
+ * qq group: 582452342
+ * email   : 2091938785@qq.com
+ * author  : 美丽的地球啊 - mliu
+ * ================================================================================ */
+type (
+	//零值表示不变换
+	GlyphTransform struct {
+		Rotation  float64 //最大旋转角度（度），在 [-Rotation, Rotation] 内随机
+		ScaleXMin float64 //水平缩放范围，均为0时不缩放
+		ScaleXMax float64
+		ScaleYMin float64 //垂直缩放范围，均为0时不缩放
+		ScaleYMax float64
+		Shear     float64 //最大水平错切系数，在 [-Shear, Shear] 内随机
+	}
+)
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 是否需要变换
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s GlyphTransform) IsZero() bool {
+	return s == GlyphTransform{}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 随机生成 旋转 x 错切 x 缩放 的2x2矩阵
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func (s GlyphTransform) matrix(random IRandom) [4]float64 {
+	angle := randFloatRange(random, -s.Rotation, s.Rotation) * math.Pi / 180
+	shear := randFloatRange(random, -s.Shear, s.Shear)
+
+	scaleX, scaleY := 1.0, 1.0
+	if s.ScaleXMin > 0 || s.ScaleXMax > 0 {
+		scaleX = randFloatRange(random, s.ScaleXMin, s.ScaleXMax)
+	}
+	if s.ScaleYMin > 0 || s.ScaleYMax > 0 {
+		scaleY = randFloatRange(random, s.ScaleYMin, s.ScaleYMax)
+	}
+
+	sin, cos := math.Sincos(angle)
+
+	//R * Sh * S
+	return [4]float64{
+		cos * scaleX, (cos*shear - sin) * scaleY,
+		sin * scaleX, (sin*shear + cos) * scaleY,
+	}
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 以src中的center为中心做线性变换后，将center对齐到dst中的target并叠加绘制，
+ * 使用双线性插值采样
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func drawTransformed(dst draw.Image, src image.Image, center, target [2]float64, matrix [4]float64) {
+	affine := getTransformAffine(center, target, matrix)
+
+	xdraw.BiLinear.Transform(dst, affine, src, src.Bounds(), xdraw.Over, nil)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * 获取bounds经drawTransformed同样的变换后在dst中覆盖的区域，四周各留1像素插值余量
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func transformedBounds(bounds image.Rectangle, center, target [2]float64, matrix [4]float64) image.Rectangle {
+	affine := getTransformAffine(center, target, matrix)
+
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, corner := range [][2]float64{
+		{float64(bounds.Min.X), float64(bounds.Min.Y)},
+		{float64(bounds.Max.X), float64(bounds.Min.Y)},
+		{float64(bounds.Min.X), float64(bounds.Max.Y)},
+		{float64(bounds.Max.X), float64(bounds.Max.Y)},
+	} {
+		x := affine[0]*corner[0] + affine[1]*corner[1] + affine[2]
+		y := affine[3]*corner[0] + affine[4]*corner[1] + affine[5]
+
+		minX, minY = math.Min(minX, x), math.Min(minY, y)
+		maxX, maxY = math.Max(maxX, x), math.Max(maxY, y)
+	}
+
+	return image.Rect(
+		int(math.Floor(minX))-1, int(math.Floor(minY))-1,
+		int(math.Ceil(maxX))+1, int(math.Ceil(maxY))+1,
+	)
+}
+
+/* ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
+ * src到dst的仿射矩阵：以center为中心做线性变换后平移到target
+ * ++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++ */
+func getTransformAffine(center, target [2]float64, matrix [4]float64) f64.Aff3 {
+	return f64.Aff3{
+		matrix[0], matrix[1], target[0] - (matrix[0]*center[0] + matrix[1]*center[1]),
+		matrix[2], matrix[3], target[1] - (matrix[2]*center[0] + matrix[3]*center[1]),
+	}
+}
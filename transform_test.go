@@ -0,0 +1,62 @@
+package gcaptcha
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func TestTransformedBoundsCoversDrawing(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 40, 30))
+	draw.Draw(src, src.Bounds(), image.NewUniform(color.RGBA{200, 0, 0, 255}), image.ZP, draw.Src)
+
+	transform := GlyphTransform{Rotation: 60, ScaleXMin: 0.5, ScaleXMax: 1.8, ScaleYMin: 0.5, ScaleYMax: 1.8, Shear: 0.6}
+	random := NewRandom(3)
+
+	for round := 0; round < 50; round++ {
+		matrix := transform.matrix(random)
+		center := [2]float64{20.3, 15.7}
+		target := [2]float64{60 + float64(round%7), 50.5}
+
+		dst := image.NewRGBA(image.Rect(0, 0, 120, 100))
+		drawTransformed(dst, src, center, target, matrix)
+
+		bounds := transformedBounds(src.Bounds(), center, target, matrix)
+		for y := dst.Rect.Min.Y; y < dst.Rect.Max.Y; y++ {
+			for x := dst.Rect.Min.X; x < dst.Rect.Max.X; x++ {
+				if dst.RGBAAt(x, y).A != 0 && !image.Pt(x, y).In(bounds) {
+					t.Fatalf("round %d: pixel (%d, %d) drawn outside %v", round, x, y, bounds)
+				}
+			}
+		}
+	}
+}
+
+func TestTextGlyphImagesClipped(t *testing.T) {
+	img := NewTextImage("", []string{"a", "b", "c", "d"}, 4).(*textImage)
+	img.SetOption(ImageOption{
+		CellWidth:  30,
+		CellHeight: 40,
+		FontSize:   20,
+		Random:     NewRandom(1),
+		Transform:  GlyphTransform{Rotation: 30},
+	})
+
+	if _, err := img.GetImage(); err != nil {
+		t.Fatal(err)
+	}
+
+	glyphImages, _, err := img.getGlyphImages(img.GetText())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	canvas := image.Rect(0, 0, img.width, img.height)
+	for index, glyphImage := range glyphImages {
+		bounds := glyphImage.Bounds()
+		if !bounds.In(canvas) || bounds.Dx()*bounds.Dy() >= canvas.Dx()*canvas.Dy()/2 {
+			t.Errorf("glyph %d: bounds %v not a small part of canvas %v", index, bounds, canvas)
+		}
+	}
+}